@@ -1,31 +1,43 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
 	"zatrasz75/Ads_service/configs"
 	"zatrasz75/Ads_service/internal/app"
-	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/internal/cli"
 )
 
 func main() {
-	l := logger.NewLogger()
+	// Флаги конфигурации указываются до подкоманды: ads --config path migrate up
+	opts := configs.RegisterFlags(flag.CommandLine)
+	printConfig := flag.Bool("print-config", false, "вывести действующую конфигурацию без секретов и завершить работу")
+	flag.Parse()
 
-	// Получаем текущий рабочий каталог
-	cwd, err := os.Getwd()
+	// Конфигурация
+	cfg, err := configs.Load(*opts)
 	if err != nil {
-		fmt.Println("Ошибка при получении текущего рабочего каталога:", err)
+		fmt.Fprintln(os.Stderr, "ошибка при разборе конфигурационного файла:", err)
+		os.Exit(1)
+	}
+
+	if *printConfig {
+		if err = cfg.WriteYAML(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 		return
 	}
-	// Построение абсолютного пути к файлу configs.yml
-	configPath := filepath.Join(cwd, "configs", "configs.yml")
 
-	// Конфигурация
-	cfg, err := configs.NewConfig(configPath)
+	l, err := app.NewLogger(cfg)
 	if err != nil {
-		l.Fatal("ошибка при разборе конфигурационного файла", err)
+		fmt.Fprintln(os.Stderr, "не удалось создать журнал:", err)
+		os.Exit(1)
 	}
 
-	app.Run(cfg, l)
+	// Подкоманда из аргументов, без аргументов запускается сервер
+	if err = cli.Run(cfg, l, flag.Args()); err != nil {
+		l.Fatal("ошибка выполнения команды", err)
+	}
 }
@@ -2,55 +2,181 @@ package configs
 
 import (
 	"fmt"
-	"github.com/ilyakaznacheev/cleanenv"
+	"net"
+	"net/url"
 	"time"
 )
 
+// Config Конфигурация сервиса. Поля с тегом reload:"true" можно менять без перезапуска, см. Reloader.
 type Config struct {
 	Server struct {
-		AddrPort     string        `yaml:"app-port" env:"APP_PORT" env-description:"Server port" env-default:"3232"`
-		AddrHost     string        `yaml:"app-host" env:"APP_IP" env-description:"Server host" env-default:"0.0.0.0"`
-		ReadTimeout  time.Duration `yaml:"read-timeout" env:"READ_TIMEOUT" env-description:"Server ReadTimeout" env-default:"3s"`
-		WriteTimeout time.Duration `yaml:"write-timeout" env:"WRITE_TIMEOUT" env-description:"Server WriteTimeout" env-default:"3s"`
-		IdleTimeout  time.Duration `yaml:"idle-timeout" env:"IDLE_TIMEOUT" env-description:"Server IdleTimeout" env-default:"6s"`
-		ShutdownTime time.Duration `yaml:"shutdown-timeout" env:"SHUTDOWN_TIMEOUT" env-description:"Server ShutdownTime" env-default:"10s"`
+		AddrPort          string        `yaml:"app-port" env:"APP_PORT" env-description:"Server port" env-default:"3232"`
+		AddrHost          string        `yaml:"app-host" env:"APP_IP" env-description:"Server host" env-default:"0.0.0.0"`
+		ReadTimeout       time.Duration `yaml:"read-timeout" env:"READ_TIMEOUT" env-description:"Server ReadTimeout" env-default:"3s"`
+		WriteTimeout      time.Duration `yaml:"write-timeout" env:"WRITE_TIMEOUT" env-description:"Server WriteTimeout" env-default:"3s"`
+		IdleTimeout       time.Duration `yaml:"idle-timeout" env:"IDLE_TIMEOUT" env-description:"Server IdleTimeout" env-default:"6s"`
+		ShutdownTime      time.Duration `yaml:"shutdown-timeout" env:"SHUTDOWN_TIMEOUT" env-description:"Server ShutdownTime" env-default:"10s"`
+		ReadHeaderTimeout time.Duration `yaml:"read-header-timeout" env:"READ_HEADER_TIMEOUT" env-description:"Time to read request headers" env-default:"2s"`
+		MaxHeaderBytes    int           `yaml:"max-header-bytes" env:"MAX_HEADER_BYTES" env-description:"Max size of request headers in bytes" env-default:"1048576"`
+		// Socket Сокет Unix вместо TCP адреса app-host:app-port
+		Socket      string `yaml:"socket" env:"SERVER_SOCKET" env-description:"Unix socket path to listen on instead of host and port"`
+		TLSCertFile string `yaml:"tls-cert-file" env:"SERVER_TLS_CERT_FILE" env-description:"Server certificate, enables HTTPS, reloaded on change"`
+		TLSKeyFile  string `yaml:"tls-key-file" env:"SERVER_TLS_KEY_FILE" env-description:"Server certificate key"`
+		HTTP2       bool   `yaml:"http2" env:"SERVER_HTTP2" env-description:"Allow HTTP/2 over TLS" env-default:"true"`
+		// TrustedProxies Прокси, от которых принимаются X-Forwarded-For и X-Real-IP
+		TrustedProxies []string `yaml:"trusted-proxies" env:"TRUSTED_PROXIES" env-description:"Proxy IPs or CIDR subnets allowed to set X-Forwarded-For"`
 	} `yaml:"server"`
+	Log struct {
+		Level   string   `yaml:"level" env:"LOG_LEVEL" env-description:"Log level: debug, info, warn or error" env-default:"debug" reload:"true"`
+		Format  string   `yaml:"format" env:"LOG_FORMAT" env-description:"Log record format: json or text" env-default:"text"`
+		Outputs []string `yaml:"outputs" env:"LOG_OUTPUTS" env-description:"Log targets: stdout, stderr, file" env-default:"stdout,file"`
+		File    string   `yaml:"file" env:"LOG_FILE" env-description:"Log file for the file target" env-default:"app.log"`
+		Source  bool     `yaml:"source" env:"LOG_SOURCE" env-description:"Add caller file and line to log records" env-default:"true"`
+		// Ротация файла журнала
+		MaxSize    int  `yaml:"max-size" env:"LOG_MAX_SIZE" env-description:"Log file size in megabytes that triggers rotation" env-default:"100"`
+		MaxAge     int  `yaml:"max-age" env:"LOG_MAX_AGE" env-description:"Days to keep rotated log files, 0 keeps them regardless of age" env-default:"30"`
+		MaxBackups int  `yaml:"max-backups" env:"LOG_MAX_BACKUPS" env-description:"Number of rotated log files to keep, 0 keeps all" env-default:"5"`
+		Compress   bool `yaml:"compress" env:"LOG_COMPRESS" env-description:"Gzip rotated log files" env-default:"true"`
+	} `yaml:"log"`
+	AccessLog struct {
+		Enabled    bool     `yaml:"enabled" env:"ACCESS_LOG_ENABLED" env-description:"Write an access log line for every request" env-default:"true"`
+		Format     string   `yaml:"format" env:"ACCESS_LOG_FORMAT" env-description:"Access log format: common, combined or json" env-default:"combined"`
+		Output     string   `yaml:"output" env:"ACCESS_LOG_OUTPUT" env-description:"stdout, stderr or a file path, files are rotated with the log.* settings" env-default:"stdout"`
+		SampleRate float64  `yaml:"sample-rate" env:"ACCESS_LOG_SAMPLE_RATE" env-description:"Share of requests to log, from 0 to 1, 5xx responses are always logged" env-default:"1"`
+		Exclude    []string `yaml:"exclude" env:"ACCESS_LOG_EXCLUDE" env-description:"Paths not to log, a trailing * matches a prefix" env-default:"/healthz,/readyz,/metrics"`
+	} `yaml:"access-log"`
+	Compression struct {
+		Enabled      bool     `yaml:"enabled" env:"COMPRESSION_ENABLED" env-description:"Compress responses according to Accept-Encoding" env-default:"true"`
+		MinSize      int      `yaml:"min-size" env:"COMPRESSION_MIN_SIZE" env-description:"Smallest response body in bytes worth compressing" env-default:"1024"`
+		Level        int      `yaml:"level" env:"COMPRESSION_LEVEL" env-description:"Compression level from 1 (fastest) to 9 (smallest)" env-default:"5"`
+		Encodings    []string `yaml:"encodings" env:"COMPRESSION_ENCODINGS" env-description:"Encodings in server preference order: zstd, gzip, deflate" env-default:"zstd,gzip,deflate"`
+		ContentTypes []string `yaml:"content-types" env:"COMPRESSION_CONTENT_TYPES" env-description:"Media types to compress, text/* matches every subtype" env-default:"application/json,application/x-ndjson,application/problem+json,application/yaml,text/csv,text/html,text/plain"`
+	} `yaml:"compression"`
 	Mongo struct {
-		ConnStr string `yaml:"connStr" env:"MONGO_CONN_STR" env-description:"MongoDB connection string"`
+		ConnStr Secret `yaml:"connStr" env:"MONGO_CONN_STR" env-description:"MongoDB connection string"`
 
 		Host           string `yaml:"host" env:"MONGO_HOST_DB" env-description:"db host" env-default:"localhost"`
 		User           string `yaml:"username" env:"MONGO_INITDB_ROOT_USERNAME" env-description:"db username" env-default:"admin"`
-		Password       string `yaml:"password" env:"MONGO_INITDB_ROOT_PASSWORD" env-description:"db password" env-default:"password"`
+		Password       Secret `yaml:"password" env:"MONGO_INITDB_ROOT_PASSWORD" env-description:"db password" env-default:"password"`
 		DbName         string `yaml:"db-name" env:"MONGO_DB_NAME" env-description:"db name" env-default:"mongodb"`
 		CollectionName string `yaml:"collectionName" env:"MONGO_COLLECTION_NAME" env-description:"collection name" env-default:"ads"`
 		Port           string `yaml:"port" env:"MONGO_PORT_DB" env-description:"db port" env-default:"27017"`
 
 		ConnAttempts int           `yaml:"conn-attempts" env:"MONGO_CONN_ATTEMPTS" env-description:"db ConnAttempts" env-default:"5"`
-		ConnTimeout  time.Duration `yaml:"conn-timeout" env:"MONGO_TIMEOUT" env-description:"db ConnTimeout" env-default:"1s"`
+		ConnTimeout  time.Duration `yaml:"conn-timeout" env:"MONGO_TIMEOUT" env-description:"Initial delay between connection attempts, doubles after each attempt" env-default:"1s"`
+
+		ConnDeadline           time.Duration `yaml:"conn-deadline" env:"MONGO_CONN_DEADLINE" env-description:"Total time limit for all connection attempts" env-default:"30s"`
+		ServerSelectionTimeout time.Duration `yaml:"server-selection-timeout" env:"MONGO_SERVER_SELECTION_TIMEOUT" env-description:"Time to find a suitable server for an operation" env-default:"5s"`
+		SocketTimeout          time.Duration `yaml:"socket-timeout" env:"MONGO_SOCKET_TIMEOUT" env-description:"Socket read/write timeout, 0 means no limit" env-default:"0s"`
+		MaxPoolSize            uint64        `yaml:"max-pool-size" env:"MONGO_MAX_POOL_SIZE" env-description:"Max connections in the pool" env-default:"100"`
+		MinPoolSize            uint64        `yaml:"min-pool-size" env:"MONGO_MIN_POOL_SIZE" env-description:"Min connections kept in the pool" env-default:"0"`
+		MaxConnIdleTime        time.Duration `yaml:"max-conn-idle-time" env:"MONGO_MAX_CONN_IDLE_TIME" env-description:"Idle connection lifetime, 0 means no limit" env-default:"0s"`
+		ReadPreference         string        `yaml:"read-preference" env:"MONGO_READ_PREFERENCE" env-description:"primary, primaryPreferred, secondary, secondaryPreferred or nearest" env-default:"primary"`
+		WriteConcern           string        `yaml:"write-concern" env:"MONGO_WRITE_CONCERN" env-description:"majority or number of nodes, empty means server default"`
+
+		TLS                   bool   `yaml:"tls" env:"MONGO_TLS" env-description:"Use TLS for MongoDB connections" env-default:"false"`
+		TLSCAFile             string `yaml:"tls-ca-file" env:"MONGO_TLS_CA_FILE" env-description:"CA certificate to verify the server"`
+		TLSCertFile           string `yaml:"tls-cert-file" env:"MONGO_TLS_CERT_FILE" env-description:"Client certificate"`
+		TLSKeyFile            string `yaml:"tls-key-file" env:"MONGO_TLS_KEY_FILE" env-description:"Client certificate key"`
+		TLSInsecureSkipVerify bool   `yaml:"tls-insecure-skip-verify" env:"MONGO_TLS_INSECURE_SKIP_VERIFY" env-description:"Skip server certificate verification" env-default:"false"`
+
+		IndexDryRun bool `yaml:"index-dry-run" env:"MONGO_INDEX_DRY_RUN" env-description:"Only log index changes on startup" env-default:"false"`
 	} `yaml:"mongo"`
+	Migrations struct {
+		CollectionName string        `yaml:"collectionName" env:"MIGRATIONS_COLLECTION_NAME" env-description:"Applied migrations collection name" env-default:"migrations"`
+		AutoMigrate    bool          `yaml:"auto-migrate" env:"MIGRATIONS_AUTO" env-description:"Apply pending migrations on startup" env-default:"false"`
+		LockTTL        time.Duration `yaml:"lock-ttl" env:"MIGRATIONS_LOCK_TTL" env-description:"Migration lock lifetime" env-default:"10m"`
+	} `yaml:"migrations"`
+	Api struct {
+		MaxBodyBytes   int64         `yaml:"max-body-bytes" env:"API_MAX_BODY_BYTES" env-description:"Max request body size in bytes for creating an ad" env-default:"1048576" reload:"true"`
+		BatchMaxItems  int           `yaml:"batch-max-items" env:"API_BATCH_MAX_ITEMS" env-description:"Max ads in one batch request" env-default:"1000" reload:"true"`
+		BatchMaxBytes  int64         `yaml:"batch-max-bytes" env:"API_BATCH_MAX_BYTES" env-description:"Max batch request body size in bytes" env-default:"8388608" reload:"true"`
+		ImportMaxBytes int64         `yaml:"import-max-bytes" env:"API_IMPORT_MAX_BYTES" env-description:"Max import upload size in bytes" env-default:"104857600" reload:"true"`
+		MaxLifetime    time.Duration `yaml:"max-lifetime" env:"API_MAX_LIFETIME" env-description:"Latest allowed expiresAt of an ad relative to now, 0 means no limit" env-default:"2160h" reload:"true"`
+		JobRetention   time.Duration `yaml:"job-retention" env:"API_JOB_RETENTION" env-description:"How long finished background jobs are kept" env-default:"1h"`
+	} `yaml:"api"`
+	Idempotency struct {
+		CollectionName string        `yaml:"collectionName" env:"IDEMPOTENCY_COLLECTION_NAME" env-description:"Idempotency keys collection name" env-default:"idempotency"`
+		TTL            time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-description:"Idempotency key lifetime" env-default:"24h"`
+	} `yaml:"idempotency"`
+	Metrics struct {
+		Enabled bool   `yaml:"enabled" env:"METRICS_ENABLED" env-description:"Expose Prometheus metrics" env-default:"true"`
+		Path    string `yaml:"path" env:"METRICS_PATH" env-description:"Metrics endpoint path" env-default:"/metrics"`
+	} `yaml:"metrics"`
+	Cors struct {
+		AllowedOrigins   []string      `yaml:"allowed-origins" env:"CORS_ALLOWED_ORIGINS" env-description:"Origins allowed to call the API: exact, https://*.example.com or *, empty disables CORS" reload:"true"`
+		AllowedMethods   []string      `yaml:"allowed-methods" env:"CORS_ALLOWED_METHODS" env-description:"Methods allowed for cross-origin requests" env-default:"GET,POST" reload:"true"`
+		AllowedHeaders   []string      `yaml:"allowed-headers" env:"CORS_ALLOWED_HEADERS" env-description:"Request headers allowed for cross-origin requests" env-default:"Content-Type,Idempotency-Key,X-Request-ID" reload:"true"`
+		ExposedHeaders   []string      `yaml:"exposed-headers" env:"CORS_EXPOSED_HEADERS" env-description:"Response headers readable by the browser" env-default:"X-Request-ID" reload:"true"`
+		AllowCredentials bool          `yaml:"allow-credentials" env:"CORS_ALLOW_CREDENTIALS" env-description:"Allow cookies and Authorization in cross-origin requests" env-default:"false" reload:"true"`
+		MaxAge           time.Duration `yaml:"max-age" env:"CORS_MAX_AGE" env-description:"How long browsers may cache preflight responses" env-default:"10m" reload:"true"`
+	} `yaml:"cors"`
+	Admin struct {
+		Addr         string        `yaml:"addr" env:"ADMIN_ADDR" env-description:"host:port of the admin listener with metrics, pprof, health details, config and admin API" env-default:"127.0.0.1:9090"`
+		Token        Secret        `yaml:"token" env:"ADMIN_TOKEN" env-description:"Bearer token for admin routes, may be empty only for a loopback address" reload:"true"`
+		Pprof        bool          `yaml:"pprof" env:"ADMIN_PPROF" env-description:"Expose /debug/pprof on the admin listener" env-default:"true"`
+		WriteTimeout time.Duration `yaml:"write-timeout" env:"ADMIN_WRITE_TIMEOUT" env-description:"Admin listener WriteTimeout, long enough for exports and CPU profiles" env-default:"60s"`
+	} `yaml:"admin"`
+	Tracing struct {
+		Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" env-description:"Trace exporter: none, otlp, stdout or file" env-default:"none"`
+		Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" env-description:"OTLP/HTTP traces endpoint URL" env-default:"http://localhost:4318/v1/traces"`
+		File        string  `yaml:"file" env:"TRACING_FILE" env-description:"Output file for the file exporter" env-default:"traces.json"`
+		ServiceName string  `yaml:"service-name" env:"TRACING_SERVICE_NAME" env-description:"Service name in traces" env-default:"ads-service"`
+		SampleRatio float64 `yaml:"sample-ratio" env:"TRACING_SAMPLE_RATIO" env-description:"Share of traces to record, from 0 to 1" env-default:"1"`
+	} `yaml:"tracing"`
+	Health struct {
+		Timeout    time.Duration `yaml:"timeout" env:"HEALTH_TIMEOUT" env-description:"Time limit for each readiness check" env-default:"2s"`
+		DrainDelay time.Duration `yaml:"drain-delay" env:"HEALTH_DRAIN_DELAY" env-description:"Pause between failing readiness and stopping the server, lets load balancers stop sending traffic" env-default:"0s"`
+	} `yaml:"health"`
+	Secrets struct {
+		Provider  string `yaml:"provider" env:"SECRETS_PROVIDER" env-description:"Provider for ${secret:name} references: file or env" env-default:"file"`
+		Dir       string `yaml:"dir" env:"SECRETS_DIR" env-description:"Directory with secret files for the file provider" env-default:"/run/secrets"`
+		EnvPrefix string `yaml:"env-prefix" env:"SECRETS_ENV_PREFIX" env-description:"Environment variable prefix for the env provider" env-default:"SECRET_"`
+	} `yaml:"secrets"`
+
+	// source Параметры, с которыми конфигурация была загружена, используются при перезагрузке
+	source LoadOptions
+	// connStrBuilt Строка подключения собрана из отдельных параметров, а не задана явно
+	connStrBuilt bool
 }
 
+// NewConfig Загружает конфигурацию из файла path и переменных окружения
 func NewConfig(path string) (*Config, error) {
-	var cfg Config
+	return Load(LoadOptions{Path: path})
+}
 
-	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
-		return nil, err
+// initDB Собирает строку подключения из отдельных параметров, если она не задана явно
+func (c *Config) initDB() {
+	if c.Mongo.ConnStr != "" {
+		return
 	}
-	cfg.Mongo.ConnStr = initDB(cfg)
+	c.Mongo.ConnStr = buildURI(*c)
+	c.connStrBuilt = true
+}
 
-	return &cfg, nil
+// IgnoredMongoParams Возвращает параметры подключения, заданные вместе со строкой mongo.connStr.
+// Строка подключения имеет приоритет, поэтому эти параметры не используются.
+func (c *Config) IgnoredMongoParams() []string {
+	if c.Mongo.ConnStr == "" || c.connStrBuilt {
+		return nil
+	}
+	var ignored []string
+	for _, f := range c.fields() {
+		switch f.Path {
+		case "mongo.host", "mongo.port", "mongo.username", "mongo.password":
+			if f.Value.String() != f.Default {
+				ignored = append(ignored, fmt.Sprintf("%s (%s)", f.Path, f.Env))
+			}
+		}
+	}
+	return ignored
 }
 
-func initDB(cfg Config) string {
-	if cfg.Mongo.ConnStr != "" {
-		return cfg.Mongo.ConnStr
+func buildURI(cfg Config) Secret {
+	u := url.URL{
+		Scheme: "mongodb",
+		User:   url.UserPassword(cfg.Mongo.User, cfg.Mongo.Password.Value()),
+		Host:   net.JoinHostPort(cfg.Mongo.Host, cfg.Mongo.Port),
 	}
-	return fmt.Sprintf(
-		"%s://%s:%s@%s:%s",
-		cfg.Mongo.DbName,
-		cfg.Mongo.User,
-		cfg.Mongo.Password,
-		cfg.Mongo.Host,
-		cfg.Mongo.Port,
-	)
+	return Secret(u.String())
 }
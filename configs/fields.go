@@ -0,0 +1,133 @@
+package configs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field Поле конфигурации с путём в YAML, именем переменной окружения и значением по умолчанию
+type field struct {
+	Path    string
+	Env     string
+	Default string
+	// Reload Поле можно менять без перезапуска
+	Reload bool
+	Value  reflect.Value
+}
+
+// fields Возвращает все конечные поля конфигурации в порядке объявления
+func (c *Config) fields() []field {
+	var out []field
+	walk(reflect.ValueOf(c).Elem(), "", &out)
+	return out
+}
+
+func walk(v reflect.Value, prefix string, out *[]field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = sf.Name
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			walk(fv, path, out)
+			continue
+		}
+		*out = append(*out, field{
+			Path:    path,
+			Env:     sf.Tag.Get("env"),
+			Default: sf.Tag.Get("env-default"),
+			Reload:  sf.Tag.Get("reload") == "true",
+			Value:   fv,
+		})
+	}
+}
+
+// lookup Находит поле по пути в YAML, например mongo.conn-timeout
+func (c *Config) lookup(path string) (field, bool) {
+	for _, f := range c.fields() {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// Set Устанавливает значение поля по пути в YAML, значение разбирается по типу поля
+func (c *Config) Set(path, value string) error {
+	f, ok := c.lookup(path)
+	if !ok {
+		return fmt.Errorf("неизвестный параметр конфигурации %q", path)
+	}
+	if err := setValue(f.Value, value); err != nil {
+		return fmt.Errorf("параметр %s: %w", path, err)
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, value string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("ожидается длительность, например 5s: %q", value)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("ожидается true или false: %q", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("ожидается целое число: %q", value)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("ожидается неотрицательное целое число: %q", value)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("ожидается число: %q", value)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("неподдерживаемый тип %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("неподдерживаемый тип %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package configs
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ilyakaznacheev/cleanenv"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Переменные окружения, задающие файл конфигурации и окружение
+const (
+	EnvConfigPath = "ADS_CONFIG"
+	EnvName       = "ADS_ENV"
+)
+
+// defaultPath Путь к файлу конфигурации относительно рабочего каталога или исполняемого файла
+var defaultPath = filepath.Join("configs", "configs.yml")
+
+// LoadOptions Параметры загрузки конфигурации
+type LoadOptions struct {
+	// Path Файл конфигурации. Если не задан, берётся из ADS_CONFIG или ищется configs/configs.yml
+	// в рабочем каталоге и рядом с исполняемым файлом; если файл не найден, используются только переменные окружения.
+	Path string
+	// Env Окружение, например prod: поверх основного файла читается configs.prod.yml, если он существует.
+	// Если не задано, берётся из ADS_ENV.
+	Env string
+	// Overrides Значения в виде путь=значение, например server.app-port=8080, применяются последними
+	Overrides []string
+	// Secrets Поставщик секретов для ссылок ${secret:name}. Если не задан, выбирается по разделу secrets конфигурации.
+	Secrets SecretProvider
+}
+
+// RegisterFlags Регистрирует флаги --config, --env и --set и возвращает заполняемые ими параметры загрузки
+func RegisterFlags(fs *flag.FlagSet) *LoadOptions {
+	opts := &LoadOptions{}
+	fs.StringVar(&opts.Path, "config", "", "файл конфигурации (по умолчанию $"+EnvConfigPath+" или "+defaultPath+")")
+	fs.StringVar(&opts.Env, "env", "", "окружение для файла configs.<env>.yml (по умолчанию $"+EnvName+")")
+	fs.Func("set", "значение параметра в виде путь=значение, например server.app-port=8080 (можно повторять)", func(s string) error {
+		opts.Overrides = append(opts.Overrides, s)
+		return nil
+	})
+	return opts
+}
+
+// Load Загружает конфигурацию слоями: значения по умолчанию, основной файл, файл окружения,
+// переменные окружения (в том числе <ENV>_FILE) и значения из флагов. Каждый следующий слой переопределяет предыдущий.
+// После этого ссылки ${secret:name} заменяются значениями секретов.
+// Загруженная конфигурация проверяется, при ошибках возвращается *ValidationError.
+func Load(opts LoadOptions) (*Config, error) {
+	var cfg Config
+
+	path, err := resolvePath(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		if err = readYAML(path, &cfg); err != nil {
+			return nil, err
+		}
+
+		if env := envName(opts); env != "" {
+			overlay := overlayPath(path, env)
+			if _, err = os.Stat(overlay); err == nil {
+				if err = readYAML(overlay, &cfg); err != nil {
+					return nil, err
+				}
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("файл конфигурации %s: %w", overlay, err)
+			}
+		}
+	}
+
+	// Переменные окружения и значения по умолчанию для незаполненных полей
+	if err = cleanenv.ReadEnv(&cfg); err != nil {
+		return nil, err
+	}
+	// Значения из файлов <ENV>_FILE, например секреты Docker и Kubernetes
+	if err = cfg.readEnvFiles(); err != nil {
+		return nil, err
+	}
+
+	for _, o := range opts.Overrides {
+		key, value, ok := strings.Cut(o, "=")
+		if !ok {
+			return nil, fmt.Errorf("значение %q должно быть в виде путь=значение", o)
+		}
+		if err = cfg.Set(strings.TrimSpace(key), value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = cfg.resolveSecrets(opts); err != nil {
+		return nil, err
+	}
+
+	// Проверка до подключения к базе, чтобы сообщить обо всех ошибках сразу
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	cfg.initDB()
+	cfg.source = opts
+
+	return &cfg, nil
+}
+
+// envName Возвращает окружение из параметров или из ADS_ENV
+func envName(opts LoadOptions) string {
+	if opts.Env != "" {
+		return opts.Env
+	}
+	return os.Getenv(EnvName)
+}
+
+// resolvePath Определяет файл конфигурации. Явно указанный файл должен существовать,
+// файл по умолчанию необязателен.
+func resolvePath(path string) (string, error) {
+	if path == "" {
+		path = os.Getenv(EnvConfigPath)
+	}
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("файл конфигурации %s: %w", path, err)
+		}
+		return path, nil
+	}
+
+	candidates := []string{defaultPath}
+	if exe, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(exe), defaultPath))
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c, nil
+		}
+	}
+	return "", nil
+}
+
+// overlayPath Возвращает путь к файлу окружения: configs/configs.yml -> configs/configs.prod.yml
+func overlayPath(path, env string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+// readYAML Читает файл поверх уже заполненной конфигурации: поля, которых нет в файле, не меняются
+func readYAML(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("файл конфигурации %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err = yaml.NewDecoder(f).Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("файл конфигурации %s: %w", path, err)
+	}
+	return nil
+}
+
+// WriteYAML Записывает конфигурацию в формате YAML, значения типа Secret скрыты
+func (c Config) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return fmt.Errorf("не удалось вывести конфигурацию: %w", err)
+	}
+	return enc.Close()
+}
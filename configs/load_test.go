@@ -0,0 +1,67 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_Layers(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "configs.yml")
+	writeFile(t, base, "server:\n  app-port: 1000\n  app-host: base\nmongo:\n  conn-timeout: 2s\n")
+	writeFile(t, filepath.Join(dir, "configs.prod.yml"), "server:\n  app-port: 2000\n")
+	t.Setenv("APP_IP", "from-env")
+
+	cfg, err := Load(LoadOptions{Path: base, Env: "prod", Overrides: []string{"mongo.conn-attempts=7"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Файл окружения переопределяет основной файл, остальные поля основного файла сохраняются
+	if cfg.Server.AddrPort != "2000" {
+		t.Errorf("app-port = %q, ожидалось значение из configs.prod.yml", cfg.Server.AddrPort)
+	}
+	if cfg.Mongo.ConnTimeout != 2*time.Second {
+		t.Errorf("conn-timeout = %v, ожидалось значение из основного файла", cfg.Mongo.ConnTimeout)
+	}
+	if cfg.Server.AddrHost != "from-env" {
+		t.Errorf("app-host = %q, ожидалось значение из переменной окружения", cfg.Server.AddrHost)
+	}
+	if cfg.Mongo.ConnAttempts != 7 {
+		t.Errorf("conn-attempts = %d, ожидалось значение из флага", cfg.Mongo.ConnAttempts)
+	}
+	if cfg.Server.ReadTimeout != 3*time.Second {
+		t.Errorf("read-timeout = %v, ожидалось значение по умолчанию", cfg.Server.ReadTimeout)
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	if _, err := Load(LoadOptions{Path: filepath.Join(t.TempDir(), "missing.yml")}); err == nil {
+		t.Error("ожидалась ошибка для отсутствующего файла")
+	}
+}
+
+func TestConfig_Set(t *testing.T) {
+	var cfg Config
+	if err := cfg.Set("server.read-timeout", "5s"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.ReadTimeout != 5*time.Second {
+		t.Errorf("read-timeout = %v", cfg.Server.ReadTimeout)
+	}
+	if err := cfg.Set("mongo.conn-attempts", "много"); err == nil {
+		t.Error("ожидалась ошибка разбора числа")
+	}
+	if err := cfg.Set("unknown.key", "1"); err == nil {
+		t.Error("ожидалась ошибка для неизвестного параметра")
+	}
+}
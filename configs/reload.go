@@ -0,0 +1,247 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// reloadDebounce Пауза после изменения файла: редакторы сохраняют файл несколькими операциями
+const reloadDebounce = 500 * time.Millisecond
+
+// Change Изменение параметра конфигурации при перезагрузке
+type Change struct {
+	Path string
+	Old  string
+	New  string
+	// Applied Изменение применено; изменения полей без тега reload вступают в силу после перезапуска
+	Applied bool
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, c.Old, c.New)
+}
+
+// Reloader Хранит действующую конфигурацию и перезагружает её при изменении файла или по сигналу SIGHUP.
+// Меняются только поля с тегом reload:"true", новая конфигурация проверяется до применения.
+type Reloader struct {
+	l       logger.LoggersInterface
+	current atomic.Pointer[Config]
+
+	mu   sync.Mutex
+	subs []func(*Config)
+}
+
+// NewReloader Создаёт Reloader для конфигурации, загруженной Load
+func NewReloader(cfg *Config, l logger.LoggersInterface) *Reloader {
+	r := &Reloader{l: l}
+	r.current.Store(cfg)
+	return r
+}
+
+// Current Возвращает действующую конфигурацию. Возвращённое значение не меняется, при перезагрузке создаётся новое.
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// Subscribe Регистрирует функцию, вызываемую с новой конфигурацией после каждой успешной перезагрузки
+func (r *Reloader) Subscribe(fn func(cfg *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs = append(r.subs, fn)
+}
+
+// Reload Перечитывает конфигурацию и применяет изменения полей с тегом reload.
+// При ошибке загрузки или проверки действующая конфигурация не меняется.
+func (r *Reloader) Reload() ([]Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.current.Load()
+	loaded, err := Load(old.source)
+	if err != nil {
+		r.l.Error("Новая конфигурация не применена", err)
+		return nil, err
+	}
+
+	next := *old
+	changes := diff(old, loaded)
+	applied := 0
+	for _, c := range changes {
+		if !c.Applied {
+			r.l.Warn("Изменение %s вступит в силу после перезапуска", c)
+			continue
+		}
+		applied++
+		r.l.Info("Изменение конфигурации %s", c)
+	}
+	if applied == 0 {
+		r.l.Info("Конфигурация перечитана, изменений, применяемых без перезапуска, нет")
+		return changes, nil
+	}
+
+	copyReloadable(&next, loaded)
+	r.current.Store(&next)
+	for _, fn := range r.subs {
+		fn(&next)
+	}
+	return changes, nil
+}
+
+// Watch Перезагружает конфигурацию при изменении файлов конфигурации и по сигналу SIGHUP до отмены ctx
+func (r *Reloader) Watch(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+
+	files := r.files()
+	if len(files) > 0 {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("не удалось отслеживать файл конфигурации: %w", err)
+		}
+		defer watcher.Close()
+
+		// Отслеживается каталог: при сохранении редакторы заменяют файл целиком, а Kubernetes
+		// подменяет ссылку ..data на каталог с новой версией ConfigMap, и событие приходит не для самого файла
+		dirs := map[string]struct{}{}
+		for f := range files {
+			dirs[filepath.Dir(f)] = struct{}{}
+		}
+		for dir := range dirs {
+			if err = watcher.Add(dir); err != nil {
+				return fmt.Errorf("не удалось отслеживать каталог %s: %w", dir, err)
+			}
+		}
+		events, errs = watcher.Events, watcher.Errors
+	}
+
+	versions := make(map[string]fileVersion, len(files))
+	for f := range files {
+		versions[f] = version(f)
+	}
+
+	timer := time.NewTimer(reloadDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			r.l.Info("Получен сигнал SIGHUP, перезагрузка конфигурации")
+			_, _ = r.Reload()
+		case ev := <-events:
+			if ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			// Версии обновляются при любом событии, чтобы следующее событие не сравнивалось с устаревшими
+			path, _ := filepath.Abs(ev.Name)
+			if _, ok := files[path]; replaced(versions) || ok {
+				timer.Reset(reloadDebounce)
+			}
+		case <-timer.C:
+			r.l.Info("Файл конфигурации изменён, перезагрузка конфигурации")
+			_, _ = r.Reload()
+		case err := <-errs:
+			r.l.Error("Ошибка отслеживания файла конфигурации", err)
+		}
+	}
+}
+
+// fileVersion Версия файла конфигурации: путь после разрешения символических ссылок, время изменения и размер
+type fileVersion struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// version Возвращает версию файла, для недоступного файла - нулевое значение
+func version(path string) fileVersion {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileVersion{}
+	}
+	info, err := os.Stat(real)
+	if err != nil {
+		return fileVersion{}
+	}
+	return fileVersion{path: real, modTime: info.ModTime(), size: info.Size()}
+}
+
+// replaced Сравнивает текущие версии файлов с сохранёнными и запоминает новые.
+// Так замечается замена файла через символическую ссылку, когда событие относится к другому имени в каталоге.
+func replaced(versions map[string]fileVersion) bool {
+	changed := false
+	for f, old := range versions {
+		cur := version(f)
+		if cur.path != old.path || !cur.modTime.Equal(old.modTime) || cur.size != old.size {
+			versions[f] = cur
+			changed = true
+		}
+	}
+	return changed
+}
+
+// files Возвращает абсолютные пути основного файла конфигурации и файла окружения
+func (r *Reloader) files() map[string]struct{} {
+	files := map[string]struct{}{}
+	source := r.current.Load().source
+
+	path, err := resolvePath(source.Path)
+	if err != nil || path == "" {
+		return files
+	}
+	paths := []string{path}
+	if env := envName(source); env != "" {
+		paths = append(paths, overlayPath(path, env))
+	}
+	for _, p := range paths {
+		if abs, err := filepath.Abs(p); err == nil {
+			files[abs] = struct{}{}
+		}
+	}
+	return files
+}
+
+// diff Возвращает изменённые поля, значения типа Secret скрыты
+func diff(old, next *Config) []Change {
+	oldFields, nextFields := old.fields(), next.fields()
+
+	var changes []Change
+	for i := range oldFields {
+		if reflect.DeepEqual(oldFields[i].Value.Interface(), nextFields[i].Value.Interface()) {
+			continue
+		}
+		changes = append(changes, Change{
+			Path:    oldFields[i].Path,
+			Old:     fmt.Sprint(oldFields[i].Value.Interface()),
+			New:     fmt.Sprint(nextFields[i].Value.Interface()),
+			Applied: oldFields[i].Reload,
+		})
+	}
+	return changes
+}
+
+// copyReloadable Копирует в dst значения полей с тегом reload из src
+func copyReloadable(dst, src *Config) {
+	dstFields, srcFields := dst.fields(), src.fields()
+	for i := range dstFields {
+		if dstFields[i].Reload {
+			dstFields[i].Value.Set(srcFields[i].Value)
+		}
+	}
+}
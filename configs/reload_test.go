@@ -0,0 +1,175 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Error(string, error)          {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Fatal(string, error)          {}
+func (nopLogger) Debug(string, ...interface{}) {}
+
+func TestReloader_Reload(t *testing.T) {
+	path := writeConfig(t, "server:\n  app-port: 1000\nlog:\n  level: debug\n")
+	cfg, err := Load(LoadOptions{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReloader(cfg, nopLogger{})
+	var notified *Config
+	r.Subscribe(func(c *Config) { notified = c })
+
+	writeFile(t, path, "server:\n  app-port: 2000\nlog:\n  level: warn\n")
+	changes, err := r.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("изменений %d, ожидалось 2: %v", len(changes), changes)
+	}
+
+	current := r.Current()
+	if current.Log.Level != "warn" {
+		t.Errorf("log.level = %q, ожидалось применение нового значения", current.Log.Level)
+	}
+	// Порт меняется только после перезапуска
+	if current.Server.AddrPort != "1000" {
+		t.Errorf("server.app-port = %q, ожидалось прежнее значение", current.Server.AddrPort)
+	}
+	if notified != current {
+		t.Error("подписчик не получил новую конфигурацию")
+	}
+	if cfg.Log.Level != "debug" {
+		t.Error("исходная конфигурация изменена")
+	}
+}
+
+func TestReloader_InvalidConfigKeepsCurrent(t *testing.T) {
+	path := writeConfig(t, "log:\n  level: info\n")
+	cfg, err := Load(LoadOptions{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewReloader(cfg, nopLogger{})
+
+	writeFile(t, path, "log:\n  level: verbose\n")
+	if _, err = r.Reload(); err == nil {
+		t.Fatal("ожидалась ошибка проверки конфигурации")
+	}
+	if r.Current() != cfg {
+		t.Error("некорректная конфигурация была применена")
+	}
+}
+
+func TestReloader_WatchFile(t *testing.T) {
+	path := writeConfig(t, "log:\n  level: info\n")
+	cfg, err := Load(LoadOptions{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewReloader(cfg, nopLogger{})
+	reloaded := make(chan *Config, 1)
+	r.Subscribe(func(c *Config) { reloaded <- c })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- r.Watch(ctx) }()
+
+	// Отслеживание начинается асинхронно, файл перезаписывается, пока изменение не будет замечено
+	deadline := time.After(5 * time.Second)
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case c := <-reloaded:
+			if c.Log.Level != "error" {
+				t.Errorf("log.level = %q", c.Log.Level)
+			}
+			cancel()
+			if err = <-done; err != nil {
+				t.Error(err)
+			}
+			return
+		case <-tick.C:
+			writeFile(t, path, "log:\n  level: error\n")
+		case <-deadline:
+			t.Fatal("изменение файла не применено")
+		}
+	}
+}
+
+func TestReloader_WatchSymlinkSwap(t *testing.T) {
+	// Раскладка тома ConfigMap в Kubernetes: configs.yml -> ..data/configs.yml, ..data -> каталог версии
+	dir := t.TempDir()
+	published := 0
+	publish := func(level string) {
+		published++
+		data := filepath.Join(dir, fmt.Sprintf("..%d", published))
+		if err := os.Mkdir(data, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(data, "configs.yml"), "log:\n  level: "+level+"\n")
+		tmp := filepath.Join(dir, "..data_tmp")
+		if err := os.Symlink(filepath.Base(data), tmp); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, filepath.Join(dir, "..data")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	publish("info")
+	path := filepath.Join(dir, "configs.yml")
+	if err := os.Symlink(filepath.Join("..data", "configs.yml"), path); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(LoadOptions{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewReloader(cfg, nopLogger{})
+	reloaded := make(chan *Config, 1)
+	r.Subscribe(func(c *Config) {
+		select {
+		case reloaded <- c:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- r.Watch(ctx) }()
+
+	// Отслеживание начинается асинхронно, новая версия публикуется, пока подмена не будет замечена
+	deadline := time.After(5 * time.Second)
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case c := <-reloaded:
+			if c.Log.Level != "error" {
+				t.Errorf("log.level = %q", c.Log.Level)
+			}
+			cancel()
+			if err = <-done; err != nil {
+				t.Error(err)
+			}
+			return
+		case <-tick.C:
+			publish("error")
+		case <-deadline:
+			t.Fatal("подмена ссылки ..data не применена")
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Названия поставщиков секретов
+const (
+	SecretsFile = "file"
+	SecretsEnv  = "env"
+)
+
+// redactedValue Значение, которым заменяются секреты при выводе, как в url.URL.Redacted
+const redactedValue = "xxxxx"
+
+// Secret Строка, которая не выводится в журнал и при выводе конфигурации.
+// Значение доступно только через Value.
+type Secret string
+
+// Value Возвращает значение секрета
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String Возвращает скрытое значение. В строке подключения скрывается только пароль.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	if u, err := url.Parse(string(s)); err == nil && u.Scheme != "" && u.Host != "" {
+		return u.Redacted()
+	}
+	return redactedValue
+}
+
+// GoString Скрывает значение при выводе с %#v
+func (s Secret) GoString() string {
+	return fmt.Sprintf("configs.Secret(%q)", s.String())
+}
+
+// MarshalYAML Скрывает значение при выводе конфигурации в YAML
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// MarshalJSON Скрывает значение при выводе конфигурации в JSON
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", s.String())), nil
+}
+
+// SecretProvider Источник секретов для ссылок ${secret:name} в конфигурации
+type SecretProvider interface {
+	Secret(name string) (string, error)
+}
+
+// ErrSecretNotFound Секрет не найден у поставщика
+var ErrSecretNotFound = errors.New("секрет не найден")
+
+// FileSecrets Секреты в файлах каталога, например /run/secrets в Docker и Kubernetes
+type FileSecrets struct {
+	Dir string
+}
+
+// Secret Читает секрет из файла с именем name, конечный перевод строки отбрасывается
+func (p FileSecrets) Secret(name string) (string, error) {
+	if name != filepath.Base(name) {
+		return "", fmt.Errorf("имя секрета %q не должно содержать путь", name)
+	}
+	b, err := os.ReadFile(filepath.Join(p.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s в каталоге %s", ErrSecretNotFound, name, p.Dir)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// EnvSecrets Секреты в переменных окружения: секрет db-password читается из <Prefix>DB_PASSWORD
+type EnvSecrets struct {
+	Prefix string
+}
+
+// Secret Читает секрет из переменной окружения
+func (p EnvSecrets) Secret(name string) (string, error) {
+	env := p.Prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+	value, ok := os.LookupEnv(env)
+	if !ok {
+		return "", fmt.Errorf("%w: %s в переменной %s", ErrSecretNotFound, name, env)
+	}
+	return value, nil
+}
+
+// secretProvider Возвращает поставщика секретов из параметров загрузки или из раздела secrets конфигурации
+func (c *Config) secretProvider(opts LoadOptions) (SecretProvider, error) {
+	if opts.Secrets != nil {
+		return opts.Secrets, nil
+	}
+	switch c.Secrets.Provider {
+	case SecretsFile:
+		return FileSecrets{Dir: c.Secrets.Dir}, nil
+	case SecretsEnv:
+		return EnvSecrets{Prefix: c.Secrets.EnvPrefix}, nil
+	default:
+		return nil, fmt.Errorf("secrets.provider (SECRETS_PROVIDER): неизвестный поставщик секретов %q, допустимые значения: file, env", c.Secrets.Provider)
+	}
+}
+
+// readEnvFiles Читает значения из файлов, указанных в переменных <ENV>_FILE, например MONGO_INITDB_ROOT_PASSWORD_FILE
+func (c *Config) readEnvFiles() error {
+	for _, f := range c.fields() {
+		if f.Env == "" {
+			continue
+		}
+		path := os.Getenv(f.Env + "_FILE")
+		if path == "" {
+			continue
+		}
+		if os.Getenv(f.Env) != "" {
+			return fmt.Errorf("%s: переменные %s и %s_FILE нельзя задавать одновременно", f.Path, f.Env, f.Env)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s (%s_FILE): %w", f.Path, f.Env, err)
+		}
+		if err = setValue(f.Value, strings.TrimRight(string(b), "\r\n")); err != nil {
+			return fmt.Errorf("%s (%s_FILE): %w", f.Path, f.Env, err)
+		}
+	}
+	return nil
+}
+
+// secretRef Ссылка на секрет в значении параметра: ${secret:name}
+var secretRef = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_.-]+)\}`)
+
+// resolveSecrets Подставляет значения секретов вместо ссылок ${secret:name} в строковых параметрах
+func (c *Config) resolveSecrets(opts LoadOptions) error {
+	var provider SecretProvider
+
+	for _, f := range c.fields() {
+		// Раздел secrets описывает самого поставщика и не может ссылаться на секреты
+		if f.Value.Kind() != reflect.String || strings.HasPrefix(f.Path, "secrets.") {
+			continue
+		}
+		value := f.Value.String()
+		if !strings.Contains(value, "${secret:") {
+			continue
+		}
+
+		if provider == nil {
+			p, err := c.secretProvider(opts)
+			if err != nil {
+				return err
+			}
+			provider = p
+		}
+
+		var resolveErr error
+		resolved := secretRef.ReplaceAllStringFunc(value, func(ref string) string {
+			name := secretRef.FindStringSubmatch(ref)[1]
+			secret, err := provider.Secret(name)
+			if err != nil && resolveErr == nil {
+				resolveErr = fmt.Errorf("%s: %w", f.Path, err)
+			}
+			return secret
+		})
+		if resolveErr != nil {
+			return resolveErr
+		}
+		f.Value.SetString(resolved)
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package configs
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"zatrasz75/Ads_service/pkg/accesslog"
+	"zatrasz75/Ads_service/pkg/compress"
+	"zatrasz75/Ads_service/pkg/httpx"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// Problem Ошибка в значении параметра конфигурации
+type Problem struct {
+	Path    string
+	Env     string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Env != "" {
+		return fmt.Sprintf("%s (%s): %s", p.Path, p.Env, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// ValidationError Все ошибки, найденные при проверке конфигурации
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("конфигурация содержит ошибки (%d):", len(e.Problems)))
+	for _, p := range e.Problems {
+		lines = append(lines, "  - "+p.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// validator Собирает ошибки, определяя путь и переменную окружения по адресу поля
+type validator struct {
+	fields   []field
+	problems []Problem
+}
+
+// addf Добавляет ошибку для поля, на которое указывает ptr
+func (v *validator) addf(ptr interface{}, format string, args ...interface{}) {
+	addr := reflect.ValueOf(ptr).Pointer()
+	for _, f := range v.fields {
+		if f.Value.Addr().Pointer() == addr {
+			v.problems = append(v.problems, Problem{Path: f.Path, Env: f.Env, Message: fmt.Sprintf(format, args...)})
+			return
+		}
+	}
+	v.problems = append(v.problems, Problem{Path: "?", Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(ptr *string) {
+	if strings.TrimSpace(*ptr) == "" {
+		v.addf(ptr, "обязательный параметр не задан")
+	}
+}
+
+func (v *validator) positive(ptr *time.Duration) {
+	if *ptr <= 0 {
+		v.addf(ptr, "длительность должна быть больше нуля, получено %s", *ptr)
+	}
+}
+
+func (v *validator) nonNegative(ptr *time.Duration) {
+	if *ptr < 0 {
+		v.addf(ptr, "длительность не может быть отрицательной, получено %s", *ptr)
+	}
+}
+
+// file Проверяет, что файл по указанному пути существует
+func (v *validator) file(ptr *string) {
+	if *ptr == "" {
+		return
+	}
+	if _, err := os.Stat(*ptr); err != nil {
+		v.addf(ptr, "файл недоступен: %v", err)
+	}
+}
+
+func (v *validator) port(ptr *string) {
+	n, err := strconv.Atoi(*ptr)
+	if err != nil || n < 1 || n > 65535 {
+		v.addf(ptr, "порт должен быть числом от 1 до 65535, получено %q", *ptr)
+	}
+}
+
+func (v *validator) intRange(ptr *int, min, max int) {
+	if *ptr < min || *ptr > max {
+		v.addf(ptr, "значение должно быть от %d до %d, получено %d", min, max, *ptr)
+	}
+}
+
+// Validate Проверяет конфигурацию и возвращает *ValidationError со всеми найденными ошибками
+func (c *Config) Validate() error {
+	v := &validator{fields: c.fields()}
+
+	// Сервер
+	v.required(&c.Server.AddrHost)
+	v.port(&c.Server.AddrPort)
+	v.positive(&c.Server.ReadTimeout)
+	v.positive(&c.Server.WriteTimeout)
+	v.positive(&c.Server.IdleTimeout)
+	v.positive(&c.Server.ShutdownTime)
+	v.positive(&c.Server.ReadHeaderTimeout)
+	v.intRange(&c.Server.MaxHeaderBytes, 4096, 64<<20)
+	v.file(&c.Server.TLSCertFile)
+	v.file(&c.Server.TLSKeyFile)
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		v.addf(&c.Server.TLSKeyFile, "сертификат и ключ задаются вместе: server.tls-cert-file и server.tls-key-file")
+	}
+	if _, err := httpx.ParseProxies(c.Server.TrustedProxies); err != nil {
+		v.addf(&c.Server.TrustedProxies, "%v", err)
+	}
+
+	// Журнал
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		v.addf(&c.Log.Level, "допустимые значения: debug, info, warn, error, получено %q", c.Log.Level)
+	}
+	if c.Log.Format != logger.FormatJSON && c.Log.Format != logger.FormatText {
+		v.addf(&c.Log.Format, "допустимые значения: json, text, получено %q", c.Log.Format)
+	}
+	if len(c.Log.Outputs) == 0 {
+		v.addf(&c.Log.Outputs, "не задан ни один получатель журнала, допустимые значения: stdout, stderr, file")
+	}
+	for _, out := range c.Log.Outputs {
+		switch out {
+		case "stdout", "stderr":
+		case "file":
+			v.required(&c.Log.File)
+			v.intRange(&c.Log.MaxSize, 1, 1<<20)
+			v.intRange(&c.Log.MaxAge, 0, 36500)
+			v.intRange(&c.Log.MaxBackups, 0, 10000)
+		default:
+			v.addf(&c.Log.Outputs, "допустимые значения: stdout, stderr, file, получено %q", out)
+		}
+	}
+
+	// Журнал доступа
+	if c.AccessLog.Enabled {
+		switch c.AccessLog.Format {
+		case accesslog.FormatCommon, accesslog.FormatCombined, accesslog.FormatJSON:
+		default:
+			v.addf(&c.AccessLog.Format, "допустимые значения: common, combined, json, получено %q", c.AccessLog.Format)
+		}
+		v.required(&c.AccessLog.Output)
+		if c.AccessLog.SampleRate < 0 || c.AccessLog.SampleRate > 1 {
+			v.addf(&c.AccessLog.SampleRate, "значение должно быть от 0 до 1, получено %v", c.AccessLog.SampleRate)
+		}
+		for _, p := range c.AccessLog.Exclude {
+			if !strings.HasPrefix(p, "/") {
+				v.addf(&c.AccessLog.Exclude, "путь должен начинаться с /, получено %q", p)
+			}
+		}
+	}
+
+	// Сжатие ответов
+	if c.Compression.Enabled {
+		v.intRange(&c.Compression.MinSize, 0, 1<<30)
+		v.intRange(&c.Compression.Level, 1, 9)
+		if len(c.Compression.Encodings) == 0 {
+			v.addf(&c.Compression.Encodings, "обязательный параметр не задан")
+		}
+		for _, enc := range c.Compression.Encodings {
+			switch enc {
+			case compress.Zstd, compress.Gzip, compress.Deflate:
+			default:
+				v.addf(&c.Compression.Encodings, "допустимые значения: zstd, gzip, deflate, получено %q", enc)
+			}
+		}
+		for _, t := range c.Compression.ContentTypes {
+			if !strings.Contains(t, "/") {
+				v.addf(&c.Compression.ContentTypes, "ожидается тип вида application/json или text/*, получено %q", t)
+			}
+		}
+	}
+
+	// MongoDB: строка подключения целиком или отдельные параметры. Если заданы оба варианта,
+	// используется строка подключения, а отдельные параметры перечисляет IgnoredMongoParams.
+	if c.Mongo.ConnStr != "" && !c.connStrBuilt {
+		validateURI(v, &c.Mongo.ConnStr)
+	} else {
+		v.required(&c.Mongo.User)
+		v.required(&c.Mongo.Host)
+		v.port(&c.Mongo.Port)
+	}
+	v.required(&c.Mongo.DbName)
+	v.required(&c.Mongo.CollectionName)
+	v.intRange(&c.Mongo.ConnAttempts, 1, 100)
+	v.positive(&c.Mongo.ConnTimeout)
+	v.positive(&c.Mongo.ConnDeadline)
+	v.positive(&c.Mongo.ServerSelectionTimeout)
+	v.nonNegative(&c.Mongo.SocketTimeout)
+	v.nonNegative(&c.Mongo.MaxConnIdleTime)
+	if c.Mongo.MaxPoolSize > 0 && c.Mongo.MinPoolSize > c.Mongo.MaxPoolSize {
+		v.addf(&c.Mongo.MinPoolSize, "не может быть больше mongo.max-pool-size (%d), получено %d", c.Mongo.MaxPoolSize, c.Mongo.MinPoolSize)
+	}
+	switch c.Mongo.ReadPreference {
+	case "primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest":
+	default:
+		v.addf(&c.Mongo.ReadPreference, "допустимые значения: primary, primaryPreferred, secondary, secondaryPreferred, nearest, получено %q", c.Mongo.ReadPreference)
+	}
+	if w := c.Mongo.WriteConcern; w != "" && w != "majority" {
+		if n, err := strconv.Atoi(w); err != nil || n < 0 {
+			v.addf(&c.Mongo.WriteConcern, "ожидается majority или количество узлов, получено %q", w)
+		}
+	}
+	validateTLS(v, c)
+
+	// Миграции
+	v.required(&c.Migrations.CollectionName)
+	v.positive(&c.Migrations.LockTTL)
+
+	// API
+	v.intRange(&c.Api.BatchMaxItems, 1, 100000)
+	if c.Api.MaxBodyBytes <= 0 {
+		v.addf(&c.Api.MaxBodyBytes, "размер должен быть больше нуля, получено %d", c.Api.MaxBodyBytes)
+	}
+	if c.Api.BatchMaxBytes <= 0 {
+		v.addf(&c.Api.BatchMaxBytes, "размер должен быть больше нуля, получено %d", c.Api.BatchMaxBytes)
+	}
+	if c.Api.ImportMaxBytes <= 0 {
+		v.addf(&c.Api.ImportMaxBytes, "размер должен быть больше нуля, получено %d", c.Api.ImportMaxBytes)
+	}
+	v.positive(&c.Api.JobRetention)
+	v.nonNegative(&c.Api.MaxLifetime)
+
+	// Идемпотентность
+	v.required(&c.Idempotency.CollectionName)
+	v.positive(&c.Idempotency.TTL)
+
+	// Метрики
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		v.addf(&c.Metrics.Path, "путь должен начинаться с /, получено %q", c.Metrics.Path)
+	}
+
+	// CORS
+	for _, origin := range c.Cors.AllowedOrigins {
+		if origin == "*" {
+			if c.Cors.AllowCredentials {
+				v.addf(&c.Cors.AllowCredentials, "нельзя разрешать cookie для любого источника, перечислите источники в cors.allowed-origins")
+			}
+			continue
+		}
+		if u, err := url.Parse(strings.Replace(origin, "://*.", "://", 1)); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			v.addf(&c.Cors.AllowedOrigins, "ожидается источник https://host[:port], https://*.domain или *, получено %q", origin)
+		}
+	}
+	v.nonNegative(&c.Cors.MaxAge)
+
+	// Служебный адрес: без токена допустим только локальный адрес
+	host, port, err := net.SplitHostPort(c.Admin.Addr)
+	if n, _ := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		v.addf(&c.Admin.Addr, "ожидается адрес host:port, получено %q", c.Admin.Addr)
+	} else if c.Admin.Token == "" && !isLoopback(host) {
+		v.addf(&c.Admin.Token, "обязателен, если admin.addr доступен не только локально (%s)", c.Admin.Addr)
+	}
+	if c.Admin.Addr == net.JoinHostPort(c.Server.AddrHost, c.Server.AddrPort) {
+		v.addf(&c.Admin.Addr, "совпадает с адресом основного сервера")
+	}
+	v.positive(&c.Admin.WriteTimeout)
+
+	// Трассировка
+	switch c.Tracing.Exporter {
+	case "none":
+	case "otlp":
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.addf(&c.Tracing.Endpoint, "ожидается адрес http(s)://host:port/v1/traces, получено %q", c.Tracing.Endpoint)
+		}
+	case "stdout":
+	case "file":
+		v.required(&c.Tracing.File)
+	default:
+		v.addf(&c.Tracing.Exporter, "допустимые значения: none, otlp, stdout, file, получено %q", c.Tracing.Exporter)
+	}
+	v.required(&c.Tracing.ServiceName)
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.addf(&c.Tracing.SampleRatio, "значение должно быть от 0 до 1, получено %v", c.Tracing.SampleRatio)
+	}
+
+	// Проверки готовности
+	v.positive(&c.Health.Timeout)
+	v.nonNegative(&c.Health.DrainDelay)
+
+	// Секреты
+	if c.Secrets.Provider != SecretsFile && c.Secrets.Provider != SecretsEnv {
+		v.addf(&c.Secrets.Provider, "допустимые значения: file, env, получено %q", c.Secrets.Provider)
+	}
+
+	// Служебные коллекции не должны совпадать с коллекцией объявлений и друг с другом
+	collections := map[string]struct{}{}
+	for _, ptr := range []*string{&c.Mongo.CollectionName, &c.Idempotency.CollectionName, &c.Migrations.CollectionName} {
+		if *ptr == "" {
+			continue
+		}
+		if _, ok := collections[*ptr]; ok {
+			v.addf(ptr, "коллекция %q уже используется другим параметром", *ptr)
+			continue
+		}
+		collections[*ptr] = struct{}{}
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// isLoopback Сообщает, доступен ли адрес только с этого узла
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// validateTLS Проверяет параметры TLS: файлы задаются только вместе с mongo.tls, сертификат клиента вместе с ключом
+func validateTLS(v *validator, c *Config) {
+	files := []*string{&c.Mongo.TLSCAFile, &c.Mongo.TLSCertFile, &c.Mongo.TLSKeyFile}
+	if !c.Mongo.TLS {
+		for _, ptr := range files {
+			if *ptr != "" {
+				v.addf(ptr, "задан без mongo.tls: true")
+			}
+		}
+		if c.Mongo.TLSInsecureSkipVerify {
+			v.addf(&c.Mongo.TLSInsecureSkipVerify, "задан без mongo.tls: true")
+		}
+		return
+	}
+
+	for _, ptr := range files {
+		v.file(ptr)
+	}
+	if (c.Mongo.TLSCertFile == "") != (c.Mongo.TLSKeyFile == "") {
+		v.addf(&c.Mongo.TLSKeyFile, "сертификат клиента и ключ задаются вместе: mongo.tls-cert-file и mongo.tls-key-file")
+	}
+}
+
+// validateURI Проверяет строку подключения к MongoDB
+func validateURI(v *validator, ptr *Secret) {
+	u, err := url.Parse(ptr.Value())
+	if err != nil {
+		// Текст ошибки разбора содержит строку подключения целиком, вместе с паролем
+		v.addf(ptr, "некорректная строка подключения")
+		return
+	}
+	if u.Scheme != "mongodb" && u.Scheme != "mongodb+srv" {
+		v.addf(ptr, "схема должна быть mongodb:// или mongodb+srv://, получено %q", u.Scheme)
+	}
+	if u.Host == "" {
+		v.addf(ptr, "в строке подключения не указан хост")
+	}
+}
@@ -9,19 +9,246 @@ const docTemplate = `{
     "info": {
         "description": "{{escape .Description}}",
         "title": "{{.Title}}",
-        "contact": {
-            "name": "Михаил Токмачев",
-            "url": "https://t.me/Zatrasz",
-            "email": "zatrasz@ya.ru"
-        },
+        "contact": {},
         "version": "{{.Version}}"
     },
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/config": {
+            "get": {
+                "description": "Конфигурация с учётом перезагрузки в формате YAML, пароли и токены скрыты.",
+                "produces": [
+                    "text/plain"
+                ],
+                "summary": "Действующая конфигурация",
+                "responses": {
+                    "200": {
+                        "description": "Конфигурация в формате YAML",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/export": {
+            "get": {
+                "description": "Потоковая выгрузка всех объявлений в формате CSV или NDJSON.\nПоддерживает те же параметры сортировки, что и список объявлений.\nЕсли ошибка возникла после начала выгрузки, соединение разрывается, чтобы неполный файл не был принят за целый.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "summary": "Выгрузка объявлений",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Формат выгрузки: csv или ndjson (по умолчанию ndjson)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поле для сортировки (например, creation или price)",
+                        "name": "sortField",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Порядок сортировки (asc или desc)",
+                        "name": "sortOrder",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Точка поиска в формате lat,lon",
+                        "name": "near",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Радиус поиска в километрах, используется вместе с near",
+                        "name": "radiusKm",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Файл выгрузки",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при выгрузке объявлений",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/import": {
+            "post": {
+                "description": "Загрузка объявлений из файла CSV или NDJSON. Файл передаётся телом запроса или полем file формы multipart/form-data.\nФормат определяется параметром format, заголовком Content-Type или расширением файла.\nЗагрузка выполняется фоновой задачей, каждая строка проверяется отдельно, ошибки сохраняются с номерами строк.\nСостояние задачи доступно по адресу из заголовка Location.",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Загрузка объявлений",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Формат файла: csv или ndjson",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Сохранить ID объявлений из файла, объявления с уже занятым ID не добавляются",
+                        "name": "keepIds",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка в заголовке файла",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Файл слишком большой",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении файла",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/import/{id}": {
+            "get": {
+                "description": "Возвращает состояние фоновой задачи загрузки: количество обработанных строк и ошибки с номерами строк.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Состояние загрузки объявлений",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/indexes": {
+            "get": {
+                "description": "Сравнивает индексы коллекций с реестром индексов приложения.\nСостояния: ok - совпадает с реестром, missing - отсутствует, changed - отличается от реестра, unmanaged - отсутствует в реестре.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Состояние индексов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.IndexState"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении списка индексов",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Результат каждой проверки готовности с длительностью в миллисекундах.\nКод ответа 200, если все проверки пройдены, иначе 503.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Состояние зависимостей",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Отвечает 200, пока процесс запущен и обрабатывает запросы. Зависимости не проверяются.",
+                "produces": [
+                    "text/plain"
+                ],
+                "summary": "Проверка работы процесса",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/posts": {
             "get": {
-                "description": "Метод для получения информации о конкретном объявлении по его уникальному идентификатору.\nВозвращает данные объявления, включая название, описание и цену.\nЕсли поля название объявления или цена отсутствуют возвращает ошибку 400\nЕсли запрошен параметр \"fields\" со значением \"description\", возвращает также описание объявления.",
+                "description": "Метод для получения информации о конкретном объявлении по его уникальному идентификатору.\nВозвращает данные объявления, включая название, описание, цену и местоположение, если оно указано.\nЕсли поля название объявления или цена отсутствуют возвращает ошибку 400\nЕсли запрошен параметр \"fields\" со значением \"description\", возвращает также описание объявления.",
                 "consumes": [
                     "application/json"
                 ],
@@ -66,7 +293,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Метод для добавления нового объявления в систему.\nПринимает поля: название, описание, цена (name , description , price).\nОбязательные поля: название и цена (name и price).\nВозвращает ID созданного объявления и код результата (ошибка или успех).",
+                "description": "Метод для добавления нового объявления в систему.\nПринимает поля: название, описание, цена (name , description , price) и необязательное местоположение location (lat, lon, city, address).\nНеобязательное поле expiresAt задаёт время, после которого объявление будет удалено.\nНеобязательное поле status принимает значения active, sold или archived, поле owner игнорируется.\nОбязательные поля: название и цена (name и price).\nВозвращает ID созданного объявления и код результата (ошибка или успех).",
                 "consumes": [
                     "application/json"
                 ],
@@ -83,6 +310,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.Ads"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности: повтор запроса с тем же ключом вернёт сохранённый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -98,6 +331,24 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "409": {
+                        "description": "Запрос с этим ключом идемпотентности ещё выполняется",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Ключ идемпотентности уже использован с другим запросом",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "не удалось сериализовать ответ JSON",
                         "schema": {
@@ -107,9 +358,118 @@ const docTemplate = `{
                 }
             }
         },
+        "/posts/batch": {
+            "get": {
+                "description": "Метод для получения нескольких объявлений за один запрос.\nID передаются через запятую в параметре ids, ненайденные ID возвращаются в поле missing.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Пакетное получение объявлений по списку ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID объявлений через запятую",
+                        "name": "ids",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchGetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Не удалось получить параметр ids",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком много ID в одном запросе",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении данных",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Метод для добавления нескольких объявлений одним запросом.\nКаждое объявление проверяется отдельно: невалидные объявления не добавляются, остальные сохраняются.\nВозвращает результат по каждому объявлению в порядке запроса.\nКод 200 если все объявления добавлены, 207 при частичном успехе.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Пакетное создание объявлений",
+                "parameters": [
+                    {
+                        "description": "Список объявлений",
+                        "name": "ads",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Ads"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности: повтор запроса с тем же ключом вернёт сохранённый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Пустой список объявлений",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при добавлении данных",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/posts/list": {
             "get": {
-                "description": "Метод для получения списка объявлений с возможностью сортировки по цене или дате создания, а также пагинации.\nВозвращает список объявлений с указанными параметрами сортировки и пагинации.",
+                "description": "Метод для получения списка объявлений с возможностью сортировки по цене или дате создания, а также пагинации.\nВозвращает список объявлений с указанными параметрами сортировки и пагинации.\nС параметром near возвращаются объявления рядом с точкой (в радиусе radiusKm, если задан) и расстояние до каждого из них,\nпо умолчанию объявления упорядочены по возрастанию расстояния, доступна сортировка sortField=distance.\nПустая страница возвращается как пустой массив [], а не null.\nСписок отправляется по мере чтения из базы: если ошибка возникла после начала ответа, соединение разрывается.",
                 "consumes": [
                     "application/json"
                 ],
@@ -126,7 +486,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Поле для сортировки (например, creation или price)",
+                        "description": "Поле для сортировки (например, creation, price или distance)",
                         "name": "sortField",
                         "in": "query"
                     },
@@ -135,6 +495,18 @@ const docTemplate = `{
                         "description": "Порядок сортировки (asc или desc)",
                         "name": "sortOrder",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Точка поиска в формате lat,lon (например, 55.75,37.61)",
+                        "name": "near",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Радиус поиска в километрах, используется вместе с near",
+                        "name": "radiusKm",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -143,12 +515,91 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Response"
+                                "$ref": "#/definitions/controller.listItem"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
-                        "description": "Ошибка при сериализации списка объявлений в JSON",
+                        "description": "Ошибка при получении списка объявлений",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/posts/search": {
+            "get": {
+                "description": "Метод для поиска объявлений по словам из названия и описания.\nРезультаты упорядочены по релевантности, совпадения в названии весят больше.\nСлова запроса приводятся к основе с учётом языка (русский или английский).\nНайденные слова в названии и фрагменте описания обрамляются тегами \u003cem\u003e\u003c/em\u003e.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Полнотекстовый поиск объявлений",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Поисковый запрос",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы для пагинации (по умолчанию 1)",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controller.searchItem"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Слишком длинный поисковый запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при поиске объявлений",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Отвечает 200, если MongoDB доступна, все миграции применены и сервис не завершает работу,\nиначе 503 со списком непройденных проверок. Неприменённые миграции перечисляются по версии и описанию:\nбез MIGRATIONS_AUTO сервис не готов, пока не выполнена команда migrate up.",
+                "produces": [
+                    "text/plain"
+                ],
+                "summary": "Проверка готовности",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Непройденные проверки",
                         "schema": {
                             "type": "string"
                         }
@@ -158,23 +609,257 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "controller.listItem": {
+            "type": "object",
+            "properties": {
+                "distanceKm": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                }
+            }
+        },
+        "controller.searchItem": {
+            "type": "object",
+            "properties": {
+                "highlights": {
+                    "type": "object",
+                    "properties": {
+                        "description": {
+                            "type": "string"
+                        },
+                        "name": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.Result"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Result": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latencyMs": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "jobs.Job": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/jobs.LineError"
+                    }
+                },
+                "errorsTruncated": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "jobs.LineError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.Ads": {
             "type": "object",
             "properties": {
                 "creation": {
                     "type": "string"
                 },
+                "currency": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
+                "distanceKm": {
+                    "description": "DistanceKm Расстояние до точки поиска, заполняется только при поиске по местоположению",
+                    "type": "number"
+                },
+                "expiresAt": {
+                    "description": "ExpiresAt Время, после которого объявление удаляется TTL индексом, nil для бессрочных объявлений",
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "location": {
+                    "$ref": "#/definitions/models.Location"
+                },
                 "name": {
                     "type": "string"
                 },
+                "owner": {
+                    "type": "string",
+                    "readOnly": true
+                },
                 "price": {
                     "type": "number"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "sold",
+                        "archived"
+                    ]
+                }
+            }
+        },
+        "models.BatchGetResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Ads"
+                    }
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.BatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BatchItemResult"
+                    }
+                }
+            }
+        },
+        "models.IndexState": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "type": "string"
+                },
+                "collection": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "spec": {
+                    "type": "string"
+                },
+                "state": {
+                    "description": "State ok, missing, changed или unmanaged",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Location": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "lat": {
+                    "type": "number"
+                },
+                "lon": {
+                    "type": "number"
                 }
             }
         },
@@ -191,12 +876,12 @@ const docTemplate = `{
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "1.0",
+	Version:          "",
 	Host:             "",
-	BasePath:         "/",
+	BasePath:         "",
 	Schemes:          []string{},
-	Title:            "Swagger API",
-	Description:      "ТЗ test_task_backend.\nhttps://github.com/incidentware/test_task_backend/tree/main",
+	Title:            "",
+	Description:      "",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
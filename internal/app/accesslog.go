@@ -0,0 +1,52 @@
+package app
+
+import (
+	"github.com/gorilla/mux"
+	"io"
+	"os"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/pkg/accesslog"
+	"zatrasz75/Ads_service/pkg/httpx"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// accessLog Возвращает обработчик журнала доступа, nil если журнал отключён.
+// Файл журнала доступа ротируется с параметрами раздела log.
+func accessLog(cfg *configs.Config) (mux.MiddlewareFunc, error) {
+	if !cfg.AccessLog.Enabled {
+		return nil, nil
+	}
+
+	var out io.Writer
+	switch cfg.AccessLog.Output {
+	case "stdout":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	default:
+		f, err := logger.NewFile(cfg.AccessLog.Output,
+			logger.MaxSize(cfg.Log.MaxSize),
+			logger.MaxAge(cfg.Log.MaxAge),
+			logger.MaxBackups(cfg.Log.MaxBackups),
+			logger.Compress(cfg.Log.Compress),
+		)
+		if err != nil {
+			return nil, err
+		}
+		out = f
+	}
+
+	// Адреса прокси проверены при загрузке конфигурации
+	trusted, _ := httpx.ParseProxies(cfg.Server.TrustedProxies)
+	al, err := accesslog.New(
+		accesslog.Format(cfg.AccessLog.Format),
+		accesslog.Output(out),
+		accesslog.SampleRate(cfg.AccessLog.SampleRate),
+		accesslog.Exclude(cfg.AccessLog.Exclude...),
+		accesslog.TrustedProxies(trusted),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return al.Middleware, nil
+}
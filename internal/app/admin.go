@@ -0,0 +1,29 @@
+package app
+
+import (
+	"github.com/gorilla/mux"
+	"net"
+	"net/http"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/metrics"
+	"zatrasz75/Ads_service/pkg/server"
+)
+
+// serveAdmin Запускает служебный сервер на адресе admin.addr, метрики публикуются на нём же.
+// Служебный сервер останавливается после основного, чтобы проверки и метрики были доступны во время остановки.
+func serveAdmin(cfg *configs.Config, l logger.LoggersInterface, m *metrics.Metrics, router *mux.Router, opts ...server.Option) *server.Server {
+	if m != nil {
+		router.Handle(cfg.Metrics.Path, m.Handler()).Methods(http.MethodGet)
+	}
+
+	host, port, _ := net.SplitHostPort(cfg.Admin.Addr)
+	srv := server.New(router, append([]server.Option{
+		server.OptionSet(host, port, cfg.Server.ReadTimeout, cfg.Admin.WriteTimeout, cfg.Server.IdleTimeout, cfg.Server.ShutdownTime),
+		server.ReadHeaderTimeout(cfg.Server.ReadHeaderTimeout),
+	}, opts...)...)
+	srv.Start()
+	l.Info("Служебный сервер: http://" + cfg.Admin.Addr + ", документация Swagger API: http://" + cfg.Admin.Addr + "/swagger/index.html")
+
+	return srv
+}
@@ -1,51 +1,128 @@
 package app
 
 import (
+	"context"
+	"github.com/gorilla/mux"
 	"os"
 	"os/signal"
 	"syscall"
 	"zatrasz75/Ads_service/configs"
 	"zatrasz75/Ads_service/internal/controller"
+	"zatrasz75/Ads_service/internal/jobs"
 	"zatrasz75/Ads_service/internal/repository"
 	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/metrics"
 	"zatrasz75/Ads_service/pkg/mongo"
+	"zatrasz75/Ads_service/pkg/recovery"
 	"zatrasz75/Ads_service/pkg/server"
+	"zatrasz75/Ads_service/pkg/tracing"
 )
 
 func Run(cfg *configs.Config, l logger.LoggersInterface) {
-	mg, err := mongo.New(cfg.Mongo.ConnStr, l, mongo.OptionSet(cfg.Mongo.ConnAttempts, cfg.Mongo.ConnTimeout, cfg.Mongo.DbName, cfg.Mongo.CollectionName))
+	tp, err := tracing.New(
+		tracing.Exporter(cfg.Tracing.Exporter),
+		tracing.Endpoint(cfg.Tracing.Endpoint),
+		tracing.File(cfg.Tracing.File),
+		tracing.ServiceName(cfg.Tracing.ServiceName),
+		tracing.SampleRatio(cfg.Tracing.SampleRatio),
+	)
 	if err != nil {
-		l.Fatal("нет соединения с базой данных", err)
+		l.Fatal("не удалось настроить трассировку", err)
 	}
 
-	repo := repository.New(mg, l, cfg)
+	var m *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		m = metrics.New()
+	}
 
-	router := controller.NewRouter(cfg, l, repo)
+	mg, err := Connect(cfg, l, mongo.PoolMonitor(m.PoolMonitor()))
+	if err != nil {
+		l.Fatal("нет соединения с базой данных", err)
+	}
 
-	srv := server.New(router, server.OptionSet(cfg.Server.AddrHost, cfg.Server.AddrPort, cfg.Server.ReadTimeout, cfg.Server.WriteTimeout, cfg.Server.IdleTimeout, cfg.Server.ShutdownTime))
+	autoMigrate(cfg, l, mg)
 
+	repo := repository.New(mg, l, cfg, repository.Metrics(m))
+	err = repo.EnsureIndexes(context.Background(), cfg.Mongo.IndexDryRun)
+	if err != nil {
+		l.Error("не удалось привести индексы к описанию", err)
+	}
+
+	// Перезагрузка конфигурации при изменении файла и по сигналу SIGHUP
+	reloader := configs.NewReloader(cfg, l)
+	if ls, ok := l.(logger.LevelSetter); ok {
+		reloader.Subscribe(func(cfg *configs.Config) {
+			if err := ls.SetLevel(cfg.Log.Level); err != nil {
+				l.Error("не удалось изменить уровень журнала", err)
+			}
+		})
+	}
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
 	go func() {
-		err = srv.Start()
-		if err != nil {
-			l.Error("Остановка сервера:", err)
+		if err := reloader.Watch(watchCtx); err != nil {
+			l.Error("перезагрузка конфигурации недоступна", err)
 		}
 	}()
 
-	l.Info("Запуск сервера на http://" + cfg.Server.AddrHost + ":" + cfg.Server.AddrPort)
-	l.Info("Документация Swagger API: http://" + cfg.Server.AddrHost + ":" + cfg.Server.AddrPort + "/swagger/index.html")
+	// Перехват паник подключается последним, чтобы метрики и журнал доступа видели ответ 500
+	mw := []mux.MiddlewareFunc{m.Middleware}
+	al, err := accessLog(cfg)
+	if err != nil {
+		l.Fatal("не удалось настроить журнал доступа", err)
+	}
+	if al != nil {
+		mw = append(mw, al)
+	}
+	// Сжатие внутри журнала доступа, чтобы в журнал попадал размер отправленного тела
+	cm, err := compression(cfg)
+	if err != nil {
+		l.Fatal("не удалось настроить сжатие ответов", err)
+	}
+	if cm != nil {
+		mw = append(mw, cm)
+	}
+	mw = append(mw, recovery.New(l, recovery.OnPanic(m.ObservePanic)).Middleware)
+
+	checker := newChecker(cfg, l, mg)
+	router := controller.NewRouter(cfg, l, repo, reloader, mw...)
+	registry := jobs.NewRegistry(cfg.Api.JobRetention)
+	adminRouter := controller.NewAdminRouter(cfg, l, repo, reloader, checker, registry, mw...)
+
+	srv := server.New(newCORS(cfg, reloader, router), append(serverOptions(cfg),
+		// Проверка готовности перестаёт проходить до остановки сервера, чтобы балансировщик успел убрать экземпляр
+		server.OnShutdown(checker.Drain),
+		server.DrainDelay(cfg.Health.DrainDelay),
+	)...)
+	srv.Start()
+	l.Info("Запуск сервера на " + serverURL(cfg))
+
+	// Соединения с базой закрываются после остановки обоих серверов, когда завершились все запросы
+	// и прерваны фоновые загрузки
+	adminSrv := serveAdmin(cfg, l, m, adminRouter, server.OnStop(registry.Close), server.OnStop(mg.Close))
 
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 
 	select {
 	case s := <-interrupt:
-		l.Info("принят сигнал прерывания прерывание %s", s.String())
+		l.Info("Принят сигнал %s, остановка сервера", s.String())
 	case err = <-srv.Notify():
-		l.Error("получена ошибка сигнала прерывания сервера", err)
+		l.Error("Сервер остановлен с ошибкой", err)
+	case err = <-adminSrv.Notify():
+		l.Error("Служебный сервер остановлен с ошибкой", err)
 	}
 
-	err = srv.Shutdown()
-	if err != nil {
+	if err = srv.Shutdown(); err != nil {
 		l.Error("не удалось завершить работу сервера", err)
 	}
+	if err = adminSrv.Shutdown(); err != nil {
+		l.Error("не удалось завершить работу служебного сервера", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTime)
+	defer cancel()
+	if err = tp.Shutdown(ctx); err != nil {
+		l.Error("не удалось отправить данные трассировки", err)
+	}
 }
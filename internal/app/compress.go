@@ -0,0 +1,25 @@
+package app
+
+import (
+	"github.com/gorilla/mux"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/pkg/compress"
+)
+
+// compression Возвращает обработчик сжатия ответов, nil если сжатие отключено
+func compression(cfg *configs.Config) (mux.MiddlewareFunc, error) {
+	if !cfg.Compression.Enabled {
+		return nil, nil
+	}
+
+	c, err := compress.New(
+		compress.MinSize(cfg.Compression.MinSize),
+		compress.Level(cfg.Compression.Level),
+		compress.Encodings(cfg.Compression.Encodings...),
+		compress.ContentTypes(cfg.Compression.ContentTypes...),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c.Middleware, nil
+}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"github.com/gorilla/mux"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/pkg/cors"
+)
+
+// newCORS Оборачивает публичный router обработкой CORS, правила обновляются при перезагрузке конфигурации
+func newCORS(cfg *configs.Config, reloader *configs.Reloader, router *mux.Router) *cors.Handler {
+	h := cors.New(router, corsOptions(cfg)...)
+	reloader.Subscribe(func(cfg *configs.Config) {
+		h.Update(corsOptions(cfg)...)
+	})
+	return h
+}
+
+func corsOptions(cfg *configs.Config) []cors.Option {
+	return []cors.Option{
+		cors.AllowedOrigins(cfg.Cors.AllowedOrigins...),
+		cors.AllowedMethods(cfg.Cors.AllowedMethods...),
+		cors.AllowedHeaders(cfg.Cors.AllowedHeaders...),
+		cors.ExposedHeaders(cfg.Cors.ExposedHeaders...),
+		cors.AllowCredentials(cfg.Cors.AllowCredentials),
+		cors.MaxAge(cfg.Cors.MaxAge),
+	}
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/internal/migrations"
+	"zatrasz75/Ads_service/pkg/health"
+	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/mongo"
+)
+
+// newChecker Создаёт проверки готовности: доступность MongoDB и отсутствие неприменённых миграций.
+// При MIGRATIONS_AUTO=false, по умолчанию, сервис не готов, пока миграции не применены командой migrate up,
+// поэтому проверка перечисляет неприменённые миграции в ответе /readyz.
+func newChecker(cfg *configs.Config, l logger.LoggersInterface, mg *mongo.Mongo) *health.Checker {
+	checker := health.New(health.Timeout(cfg.Health.Timeout))
+
+	checker.Add("mongo", mg.Ping)
+
+	migrator := migrations.New(mg.Database(), cfg, l)
+	checker.Add("migrations", func(ctx context.Context) error {
+		pending, err := pendingMigrations(ctx, migrator)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("неприменённые миграции %s, выполните migrate up или включите MIGRATIONS_AUTO", strings.Join(pending, ", "))
+		}
+		return nil
+	})
+
+	return checker
+}
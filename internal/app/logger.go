@@ -0,0 +1,39 @@
+package app
+
+import (
+	"os"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// NewLogger Создаёт журнал с форматом, уровнем и получателями из раздела log конфигурации.
+// Файл журнала ротируется при записи, в контейнере его можно отключить, убрав file из log.outputs.
+func NewLogger(cfg *configs.Config) (*logger.SlogLogger, error) {
+	opts := []logger.SlogOption{
+		logger.Format(cfg.Log.Format),
+		logger.Level(cfg.Log.Level),
+		logger.Source(cfg.Log.Source),
+	}
+
+	for _, out := range cfg.Log.Outputs {
+		switch out {
+		case "stdout":
+			opts = append(opts, logger.Output(os.Stdout))
+		case "stderr":
+			opts = append(opts, logger.Output(os.Stderr))
+		case "file":
+			f, err := logger.NewFile(cfg.Log.File,
+				logger.MaxSize(cfg.Log.MaxSize),
+				logger.MaxAge(cfg.Log.MaxAge),
+				logger.MaxBackups(cfg.Log.MaxBackups),
+				logger.Compress(cfg.Log.Compress),
+			)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, logger.Output(f))
+		}
+	}
+
+	return logger.NewSlog(opts...)
+}
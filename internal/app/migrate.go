@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/internal/migrations"
+	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/mongo"
+)
+
+// autoMigrate Применяет миграции при запуске сервиса или предупреждает о неприменённых
+func autoMigrate(cfg *configs.Config, l logger.LoggersInterface, mg *mongo.Mongo) {
+	migrator := migrations.New(mg.Database(), cfg, l)
+
+	if !cfg.Migrations.AutoMigrate {
+		pending, err := pendingMigrations(context.Background(), migrator)
+		if err != nil {
+			l.Error("не удалось получить состояние миграций", err)
+			return
+		}
+		if len(pending) > 0 {
+			l.Warn("Есть неприменённые миграции: %s, до их применения /readyz отвечает 503; выполните migrate up или включите MIGRATIONS_AUTO", strings.Join(pending, ", "))
+		}
+		return
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		l.Fatal("не удалось применить миграции", err)
+	}
+	if applied > 0 {
+		l.Info("Применено миграций: %d", applied)
+	}
+}
+
+// pendingMigrations Возвращает неприменённые миграции в виде "версия (описание)"
+func pendingMigrations(ctx context.Context, migrator *migrations.Migrator) ([]string, error) {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, st := range statuses {
+		if !st.Applied {
+			pending = append(pending, fmt.Sprintf("%d (%s)", st.Version, st.Description))
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/mongo"
+)
+
+// Connect Подключается к MongoDB со всеми параметрами из раздела mongo конфигурации, extra дополняет их
+func Connect(cfg *configs.Config, l logger.LoggersInterface, extra ...mongo.Option) (*mongo.Mongo, error) {
+	opts := []mongo.Option{
+		mongo.OptionSet(cfg.Mongo.ConnAttempts, cfg.Mongo.ConnTimeout, cfg.Mongo.DbName, cfg.Mongo.CollectionName),
+		mongo.ConnDeadline(cfg.Mongo.ConnDeadline),
+		mongo.Pool(cfg.Mongo.MaxPoolSize, cfg.Mongo.MinPoolSize, cfg.Mongo.MaxConnIdleTime),
+		mongo.Timeouts(cfg.Mongo.ServerSelectionTimeout, cfg.Mongo.SocketTimeout),
+		mongo.ReadPreference(cfg.Mongo.ReadPreference),
+		mongo.WriteConcern(cfg.Mongo.WriteConcern),
+	}
+	if cfg.Mongo.TLS {
+		tlsCfg, err := mongo.TLSConfig(cfg.Mongo.TLSCAFile, cfg.Mongo.TLSCertFile, cfg.Mongo.TLSKeyFile, cfg.Mongo.TLSInsecureSkipVerify)
+		if err != nil {
+			return nil, fmt.Errorf("параметры TLS MongoDB: %w", err)
+		}
+		opts = append(opts, mongo.TLS(tlsCfg))
+	}
+
+	if ignored := cfg.IgnoredMongoParams(); len(ignored) > 0 {
+		l.Warn("Задана строка подключения mongo.connStr, параметры не используются: %s", strings.Join(ignored, ", "))
+	}
+
+	return mongo.New(cfg.Mongo.ConnStr.Value(), l, append(opts, extra...)...)
+}
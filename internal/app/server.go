@@ -0,0 +1,36 @@
+package app
+
+import (
+	"net"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/pkg/server"
+)
+
+// serverOptions Параметры основного сервера из раздела server конфигурации
+func serverOptions(cfg *configs.Config) []server.Option {
+	opts := []server.Option{
+		server.OptionSet(cfg.Server.AddrHost, cfg.Server.AddrPort, cfg.Server.ReadTimeout, cfg.Server.WriteTimeout, cfg.Server.IdleTimeout, cfg.Server.ShutdownTime),
+		server.ReadHeaderTimeout(cfg.Server.ReadHeaderTimeout),
+		server.MaxHeaderBytes(cfg.Server.MaxHeaderBytes),
+		server.HTTP2(cfg.Server.HTTP2),
+	}
+	if cfg.Server.TLSCertFile != "" {
+		opts = append(opts, server.TLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile))
+	}
+	if cfg.Server.Socket != "" {
+		opts = append(opts, server.Unix(cfg.Server.Socket))
+	}
+	return opts
+}
+
+// serverURL Адрес основного сервера для сообщений в журнале
+func serverURL(cfg *configs.Config) string {
+	if cfg.Server.Socket != "" {
+		return "unix:" + cfg.Server.Socket
+	}
+	scheme := "http://"
+	if cfg.Server.TLSCertFile != "" {
+		scheme = "https://"
+	}
+	return scheme + net.JoinHostPort(cfg.Server.AddrHost, cfg.Server.AddrPort)
+}
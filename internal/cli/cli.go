@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/internal/app"
+	"zatrasz75/Ads_service/internal/repository"
+	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/mongo"
+)
+
+// command Подкоманда исполняемого файла
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, cfg *configs.Config, l logger.LoggersInterface, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "запуск HTTP сервера (по умолчанию)", serve},
+		{"migrate", "миграции схемы: up | down [шагов] | status", migrate},
+		{"seed", "добавление случайных объявлений: --count N", seed},
+		{"export", "выгрузка объявлений: --format csv|ndjson --out файл", export},
+		{"import", "загрузка объявлений: --file файл [--format csv|ndjson]", importAds},
+		{"reindex", "приведение индексов к реестру: [--dry-run]", reindex},
+		{"config", "конфигурация: print", config},
+		{"healthcheck", "проверка доступности запущенного сервера: [--url адрес] [--timeout 3s]", healthcheck},
+		{"help", "список команд", help},
+	}
+}
+
+// Run Выполняет подкоманду из аргументов командной строки, без аргументов запускается сервер
+func Run(cfg *configs.Config, l logger.LoggersInterface, args []string) error {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		// Команды обслуживания прерываются по Ctrl+C, сервер обрабатывает сигналы сам
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		err := cmd.run(ctx, cfg, l, args)
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	printUsage()
+	return fmt.Errorf("неизвестная команда %q", name)
+}
+
+func serve(_ context.Context, cfg *configs.Config, l logger.LoggersInterface, _ []string) error {
+	app.Run(cfg, l)
+	return nil
+}
+
+func help(context.Context, *configs.Config, logger.LoggersInterface, []string) error {
+	printUsage()
+	return nil
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Использование: ads <команда> [параметры]")
+	fmt.Fprintln(os.Stderr, "\nКоманды:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+// newFlagSet Создаёт набор флагов подкоманды, ошибки разбора возвращаются вызывающему
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// connect Подключается к MongoDB с параметрами из конфигурации
+func connect(cfg *configs.Config, l logger.LoggersInterface) (*mongo.Mongo, error) {
+	mg, err := app.Connect(cfg, l)
+	if err != nil {
+		return nil, fmt.Errorf("нет соединения с базой данных: %w", err)
+	}
+	return mg, nil
+}
+
+// openStore Подключается к MongoDB и создаёт хранилище объявлений, close закрывает соединение
+func openStore(cfg *configs.Config, l logger.LoggersInterface) (store *repository.Store, close func(), err error) {
+	mg, err := connect(cfg, l)
+	if err != nil {
+		return nil, nil, err
+	}
+	return repository.New(mg, l, cfg), func() { _ = mg.Close(context.Background()) }, nil
+}
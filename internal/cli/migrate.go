@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/internal/migrations"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// migrate Выполняет команду миграций: up, down [шагов] или status
+func migrate(ctx context.Context, cfg *configs.Config, l logger.LoggersInterface, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("не указана команда миграций, ожидается up, down или status")
+	}
+
+	mg, err := connect(cfg, l)
+	if err != nil {
+		return err
+	}
+	defer mg.Close(context.Background())
+
+	migrator := migrations.New(mg.Database(), cfg, l)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		l.Info("Применено миграций: %d", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("количество шагов отката должно быть положительным числом: %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		l.Info("Откачено миграций: %d", reverted)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			state := "не применена"
+			if st.Applied {
+				state = "применена " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%4d  %-60s %s\n", st.Version, st.Description, state)
+		}
+	default:
+		return fmt.Errorf("неизвестная команда миграций %q, ожидается up, down или status", args[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// config Выводит действующую конфигурацию в формате YAML, пароли скрыты
+func config(_ context.Context, cfg *configs.Config, _ logger.LoggersInterface, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("ожидается команда config print")
+	}
+
+	return cfg.WriteYAML(os.Stdout)
+}
+
+// healthcheck Проверяет, что запущенный сервер отвечает. Ненулевой код выхода означает, что сервер недоступен,
+// поэтому команду можно использовать в HEALTHCHECK контейнера.
+func healthcheck(ctx context.Context, cfg *configs.Config, _ logger.LoggersInterface, args []string) error {
+	fs := newFlagSet("healthcheck")
+	addr := fs.String("url", "", "адрес проверки (по умолчанию адрес сервера из конфигурации)")
+	timeout := fs.Duration("timeout", 3*time.Second, "время ожидания ответа")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	target := *addr
+	if target == "" {
+		target = localTarget(cfg)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("сервер недоступен: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("сервер ответил %s", resp.Status)
+	}
+	return nil
+}
+
+// localTarget Возвращает адрес /healthz служебного сервера, запущенного по конфигурации
+func localTarget(cfg *configs.Config) string {
+	host, port, _ := net.SplitHostPort(cfg.Admin.Addr)
+	// Сервер слушает все интерфейсы, проверяем через локальный
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/healthz"
+}
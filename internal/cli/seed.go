@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/models"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// seedItem Шаблон товара для случайных объявлений
+type seedItem struct {
+	name     string
+	details  []string
+	minPrice float64
+	maxPrice float64
+}
+
+var seedItems = []seedItem{
+	{"Велосипед горный", []string{"рама 19 дюймов", "21 скорость", "дисковые тормоза", "новые покрышки"}, 8000, 45000},
+	{"Смартфон", []string{"128 ГБ", "без царапин", "полный комплект", "чек и гарантия"}, 5000, 90000},
+	{"Ноутбук", []string{"16 ГБ ОЗУ", "SSD 512 ГБ", "батарея держит 5 часов", "зарядка в комплекте"}, 15000, 150000},
+	{"Диван угловой", []string{"раскладной", "ящик для белья", "обивка из велюра", "без животных"}, 7000, 60000},
+	{"Детская коляска", []string{"2 в 1", "люлька и прогулочный блок", "дождевик в подарок"}, 4000, 35000},
+	{"Холодильник", []string{"двухкамерный", "No Frost", "высота 185 см", "тихий"}, 6000, 55000},
+	{"Зимние шины", []string{"R16", "шипованные", "комплект 4 штуки", "остаток протектора 80%"}, 8000, 30000},
+	{"Кофемашина", []string{"автоматическая", "капучинатор", "после сервиса"}, 5000, 40000},
+	{"Гитара акустическая", []string{"ель и махагони", "чехол в комплекте", "новые струны"}, 3000, 25000},
+	{"Палатка туристическая", []string{"четырёхместная", "два тамбура", "водостойкость 3000 мм"}, 2500, 18000},
+	{"Письменный стол", []string{"120x60 см", "два ящика", "светлое дерево"}, 1500, 12000},
+	{"Игровая приставка", []string{"два геймпада", "пять игр", "на гарантии"}, 15000, 55000},
+}
+
+var seedConditions = []string{"Новый", "Как новый", "Отличное состояние", "Б/у", "Хорошее состояние"}
+
+// seedCities Города и их координаты, объявления располагаются в пределах нескольких километров от центра
+var seedCities = []models.Location{
+	{City: "Москва", Lat: 55.7558, Lon: 37.6173},
+	{City: "Санкт-Петербург", Lat: 59.9343, Lon: 30.3351},
+	{City: "Казань", Lat: 55.7963, Lon: 49.1088},
+	{City: "Новосибирск", Lat: 55.0084, Lon: 82.9357},
+	{City: "Екатеринбург", Lat: 56.8389, Lon: 60.6057},
+	{City: "Нижний Новгород", Lat: 56.2965, Lon: 43.9361},
+}
+
+var seedStreets = []string{"ул. Ленина", "пр. Мира", "ул. Садовая", "ул. Гагарина", "ул. Советская", "наб. Речная"}
+
+// seed Добавляет случайные объявления, похожие на настоящие
+func seed(ctx context.Context, cfg *configs.Config, l logger.LoggersInterface, args []string) error {
+	fs := newFlagSet("seed")
+	count := fs.Int("count", 100, "количество объявлений")
+	randSeed := fs.Int64("seed", time.Now().UnixNano(), "начальное значение генератора для воспроизводимых данных")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *count < 1 {
+		return fmt.Errorf("количество объявлений должно быть положительным: %d", *count)
+	}
+
+	store, closeStore, err := openStore(cfg, l)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	rnd := rand.New(rand.NewSource(*randSeed))
+	batchSize := cfg.Api.BatchMaxItems
+	now := time.Now()
+
+	created, failed := 0, 0
+	for created+failed < *count {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		n := min(batchSize, *count-created-failed)
+		batch := make([]models.Ads, 0, n)
+		for i := 0; i < n; i++ {
+			batch = append(batch, fakeAd(rnd, now))
+		}
+
+		results, err := store.AddPosts(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for _, res := range results {
+			if res.Error != "" {
+				failed++
+				continue
+			}
+			created++
+		}
+	}
+
+	l.Info("Добавлено объявлений: %d, с ошибками: %d", created, failed)
+	return nil
+}
+
+// fakeAd Создаёт случайное объявление
+func fakeAd(rnd *rand.Rand, now time.Time) models.Ads {
+	item := seedItems[rnd.Intn(len(seedItems))]
+
+	details := append([]string(nil), item.details...)
+	rnd.Shuffle(len(details), func(i, j int) { details[i], details[j] = details[j], details[i] })
+	details = details[:1+rnd.Intn(len(details))]
+
+	price := item.minPrice + rnd.Float64()*(item.maxPrice-item.minPrice)
+	// Цены округляются до сотен, как обычно пишут в объявлениях
+	price = math.Round(price/100) * 100
+
+	ad := models.Ads{
+		Name:        item.name,
+		Description: seedConditions[rnd.Intn(len(seedConditions))] + ", " + strings.Join(details, ", ") + ".",
+		Price:       price,
+		Creation:    now.Add(-time.Duration(rnd.Int63n(int64(90 * 24 * time.Hour)))),
+	}
+
+	// Часть объявлений без адреса, как и у настоящих пользователей
+	if rnd.Intn(10) < 7 {
+		city := seedCities[rnd.Intn(len(seedCities))]
+		ad.Location = &models.Location{
+			City:    city.City,
+			Address: fmt.Sprintf("%s, %d", seedStreets[rnd.Intn(len(seedStreets))], 1+rnd.Intn(150)),
+			// Около 0.05 градуса: несколько километров от центра города
+			Lat: city.Lat + (rnd.Float64()-0.5)*0.1,
+			Lon: city.Lon + (rnd.Float64()-0.5)*0.1,
+		}
+	}
+
+	return ad
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestFakeAd(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	now := time.Now()
+
+	for i := 0; i < 500; i++ {
+		ad := fakeAd(rnd, now)
+		if ad.Name == "" || ad.Description == "" {
+			t.Fatalf("пустое название или описание: %+v", ad)
+		}
+		if ad.Price <= 0 {
+			t.Fatalf("цена должна быть положительной: %v", ad.Price)
+		}
+		if ad.Creation.After(now) || ad.Creation.Before(now.Add(-90*24*time.Hour)) {
+			t.Fatalf("дата создания вне последних 90 дней: %v", ad.Creation)
+		}
+		if ad.Location != nil && !ad.Location.Valid() {
+			t.Fatalf("некорректные координаты: %+v", ad.Location)
+		}
+	}
+}
+
+func TestFakeAd_Reproducible(t *testing.T) {
+	now := time.Now()
+	a := fakeAd(rand.New(rand.NewSource(42)), now)
+	b := fakeAd(rand.New(rand.NewSource(42)), now)
+	if a.Name != b.Name || a.Description != b.Description || a.Price != b.Price {
+		t.Errorf("одинаковое начальное значение дало разные объявления: %+v и %+v", a, b)
+	}
+}
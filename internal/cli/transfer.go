@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/internal/app"
+	"zatrasz75/Ads_service/internal/jobs"
+	"zatrasz75/Ads_service/internal/transfer"
+	"zatrasz75/Ads_service/models"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// export Выгружает объявления в файл или в стандартный вывод
+func export(ctx context.Context, cfg *configs.Config, l logger.LoggersInterface, args []string) error {
+	fs := newFlagSet("export")
+	formatFlag := fs.String("format", "", "формат выгрузки: csv или ndjson (по умолчанию по расширению файла или ndjson)")
+	out := fs.String("out", "-", "файл выгрузки, - для стандартного вывода")
+	sortField := fs.String("sortField", "", "поле для сортировки: creation или price")
+	sortOrder := fs.String("sortOrder", "asc", "порядок сортировки: asc или desc")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := transfer.ParseFormat(formatOrExt(*formatFlag, *out))
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		if l, err = stderrLogger(cfg); err != nil {
+			return err
+		}
+	}
+
+	store, closeStore, err := openStore(cfg, l)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("не удалось создать файл выгрузки: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	tw, err := transfer.NewWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	filter := models.ListFilter{SortField: *sortField, SortOrder: *sortOrder}
+	err = store.ExportPosts(ctx, filter, func(ad models.Ads) error {
+		count++
+		return tw.Write(ad)
+	})
+	if err != nil {
+		return err
+	}
+	if err = tw.Flush(); err != nil {
+		return err
+	}
+
+	l.Info("Выгружено объявлений: %d в %s", count, *out)
+	return nil
+}
+
+// stderrLogger Создаёт журнал с получателем stderr вместо stdout, чтобы записи журнала
+// не смешивались с данными, которые команда выводит в стандартный вывод
+func stderrLogger(cfg *configs.Config) (logger.LoggersInterface, error) {
+	c := *cfg
+	c.Log.Outputs = stderrOutputs(cfg.Log.Outputs)
+
+	l, err := app.NewLogger(&c)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать журнал: %w", err)
+	}
+	return l, nil
+}
+
+// stderrOutputs Заменяет получателя stdout на stderr без повторов
+func stderrOutputs(outputs []string) []string {
+	replaced := make([]string, 0, len(outputs))
+	for _, out := range outputs {
+		if out == "stdout" {
+			out = "stderr"
+		}
+		if !slices.Contains(replaced, out) {
+			replaced = append(replaced, out)
+		}
+	}
+	return replaced
+}
+
+// importAds Загружает объявления из файла тем же загрузчиком, что и /admin/import
+func importAds(ctx context.Context, cfg *configs.Config, l logger.LoggersInterface, args []string) error {
+	fs := newFlagSet("import")
+	formatFlag := fs.String("format", "", "формат файла: csv или ndjson (по умолчанию по расширению файла)")
+	file := fs.String("file", "", "файл для загрузки, - для стандартного ввода")
+	keepIDs := fs.Bool("keep-ids", false, "сохранить ID объявлений из файла")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("не указан файл для загрузки: --file")
+	}
+
+	raw := formatOrExt(*formatFlag, *file)
+	if raw == "" {
+		return fmt.Errorf("не удалось определить формат файла, укажите --format")
+	}
+	format, err := transfer.ParseFormat(raw)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return fmt.Errorf("не удалось открыть файл: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	reader, err := transfer.NewReader(r, format)
+	if err != nil {
+		return fmt.Errorf("ошибка в заголовке файла: %w", err)
+	}
+
+	store, closeStore, err := openStore(cfg, l)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	importer := transfer.NewImporter(store,
+		transfer.BatchSize(cfg.Api.BatchMaxItems),
+		transfer.MaxLifetime(cfg.Api.MaxLifetime),
+		transfer.KeepIDs(*keepIDs),
+	)
+	job := jobs.Run(ctx, "import", importer.Job(reader))
+
+	for _, e := range job.Errors {
+		fmt.Fprintf(os.Stderr, "строка %d: %s\n", e.Line, e.Error)
+	}
+	if job.Truncated {
+		fmt.Fprintln(os.Stderr, "показаны не все ошибки")
+	}
+	l.Info("Обработано строк: %d, добавлено: %d, с ошибками: %d", job.Processed, job.Succeeded, job.Failed)
+
+	if job.Status == jobs.StatusFailed {
+		return fmt.Errorf("загрузка прервана: %s", job.Error)
+	}
+	return nil
+}
+
+// reindex Приводит индексы к реестру и выводит их состояние
+func reindex(ctx context.Context, cfg *configs.Config, l logger.LoggersInterface, args []string) error {
+	fs := newFlagSet("reindex")
+	dryRun := fs.Bool("dry-run", false, "только показать, какие индексы будут изменены")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, closeStore, err := openStore(cfg, l)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	if err = store.EnsureIndexes(ctx, *dryRun); err != nil {
+		return err
+	}
+
+	states, err := store.IndexStates(ctx)
+	if err != nil {
+		return err
+	}
+	for _, st := range states {
+		fmt.Printf("%-14s %-24s %-10s %s\n", st.Collection, st.Name, st.State, st.Spec)
+	}
+	return nil
+}
+
+// formatOrExt Возвращает формат из параметра или по расширению файла
+func formatOrExt(format, file string) string {
+	if format != "" {
+		return format
+	}
+	return strings.TrimPrefix(filepath.Ext(file), ".")
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStderrOutputs(t *testing.T) {
+	tests := []struct {
+		outputs []string
+		want    []string
+	}{
+		{outputs: []string{"stdout", "file"}, want: []string{"stderr", "file"}},
+		{outputs: []string{"stderr", "stdout"}, want: []string{"stderr"}},
+		{outputs: []string{"file"}, want: []string{"file"}},
+	}
+	for _, tt := range tests {
+		if got := stderrOutputs(tt.outputs); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("stderrOutputs(%v) = %v, ожидалось %v", tt.outputs, got, tt.want)
+		}
+	}
+}
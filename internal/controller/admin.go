@@ -0,0 +1,298 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"zatrasz75/Ads_service/internal/jobs"
+	"zatrasz75/Ads_service/internal/transfer"
+	"zatrasz75/Ads_service/models"
+	"zatrasz75/Ads_service/pkg/httpx"
+)
+
+// exportFlushEvery Через сколько объявлений выгрузка сбрасывается клиенту
+const exportFlushEvery = 100
+
+// @Summary Выгрузка объявлений
+// @Description Потоковая выгрузка всех объявлений в формате CSV или NDJSON.
+// @Description Поддерживает те же параметры сортировки, что и список объявлений.
+// @Description Если ошибка возникла после начала выгрузки, соединение разрывается, чтобы неполный файл не был принят за целый.
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Формат выгрузки: csv или ndjson (по умолчанию ndjson)"
+// @Param sortField query string false "Поле для сортировки (например, creation или price)"
+// @Param sortOrder query string false "Порядок сортировки (asc или desc)"
+// @Param near query string false "Точка поиска в формате lat,lon"
+// @Param radiusKm query number false "Радиус поиска в километрах, используется вместе с near"
+// @Success 200 {string} string "Файл выгрузки"
+// @Failure 400 {string} string "неизвестный формат, допустимые значения: csv, ndjson"
+// @Failure 400 {string} string "Некорректные параметры запроса"
+// @Failure 500 {string} string "Ошибка при выгрузке объявлений"
+// @Router /admin/export [get]
+// @OperationId exportPosts
+func (a *api) exportPosts(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	format, err := transfer.ParseFormat(queryParams.Get("format"))
+	if err != nil {
+		a.log(r.Context()).Debug(err.Error())
+		httpError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseListFilter(queryParams)
+	if err != nil {
+		a.log(r.Context()).Debug(err.Error())
+		httpError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", transfer.ContentType(format))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "ads." + format}))
+
+	// Recorder показывает, отправлено ли начало ответа: буферы записи могут отправить его до первого сброса
+	rec := httpx.NewRecorder(w)
+	tw, err := transfer.NewWriter(rec, format)
+	if err != nil {
+		a.log(r.Context()).Error("Ошибка при выгрузке объявлений", err)
+		httpError(w, r, "Ошибка при выгрузке объявлений", http.StatusInternalServerError)
+		return
+	}
+
+	count := 0
+	err = a.repo.ExportPosts(r.Context(), filter, func(ad models.Ads) error {
+		if err := tw.Write(ad); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+			rec.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = tw.Flush()
+	}
+	if err != nil {
+		a.log(r.Context()).Error("Ошибка при выгрузке объявлений", err)
+		if !rec.WroteHeader() {
+			w.Header().Del("Content-Disposition")
+			httpError(w, r, "Ошибка при выгрузке объявлений", http.StatusInternalServerError)
+			return
+		}
+		// Начало файла уже отправлено с кодом 200, обрыв соединения не даст принять неполную выгрузку за целую
+		panic(http.ErrAbortHandler)
+	}
+	a.log(r.Context()).Info("Выгружено объявлений: %d", count)
+}
+
+// @Summary Загрузка объявлений
+// @Description Загрузка объявлений из файла CSV или NDJSON. Файл передаётся телом запроса или полем file формы multipart/form-data.
+// @Description Формат определяется параметром format, заголовком Content-Type или расширением файла.
+// @Description Загрузка выполняется фоновой задачей, каждая строка проверяется отдельно, ошибки сохраняются с номерами строк.
+// @Description Состояние задачи доступно по адресу из заголовка Location.
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Accept multipart/form-data
+// @Produce json
+// @Param format query string false "Формат файла: csv или ndjson"
+// @Param keepIds query bool false "Сохранить ID объявлений из файла, объявления с уже занятым ID не добавляются"
+// @Success 202 {object} jobs.Job
+// @Failure 400 {string} string "Не удалось определить формат файла"
+// @Failure 400 {string} string "Ошибка в заголовке файла"
+// @Failure 413 {string} string "Файл слишком большой"
+// @Failure 500 {string} string "Ошибка при сохранении файла"
+// @Router /admin/import [post]
+// @OperationId importPosts
+func (a *api) importPosts(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, a.config().Api.ImportMaxBytes)
+	body := io.Reader(r.Body)
+	format := r.URL.Query().Get("format")
+	keepIDs, _ := strconv.ParseBool(r.URL.Query().Get("keepIds"))
+	contentType := r.Header.Get("Content-Type")
+
+	// Файл из формы multipart/form-data
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			a.log(r.Context()).Error("не удалось прочитать форму", err)
+			httpError(w, r, "не удалось прочитать форму", http.StatusBadRequest)
+			return
+		}
+		part, err := nextFilePart(mr)
+		if err != nil {
+			a.log(r.Context()).Debug("В форме отсутствует поле file")
+			httpError(w, r, "В форме отсутствует поле file", http.StatusBadRequest)
+			return
+		}
+		defer part.Close()
+		body = part
+		contentType = part.Header.Get("Content-Type")
+		if format == "" {
+			format = filepath.Ext(part.FileName())
+			if len(format) > 0 {
+				format = format[1:]
+			}
+		}
+	}
+
+	if format == "" {
+		format, _ = transfer.FormatFromContentType(contentType)
+	}
+	if format == "" {
+		a.log(r.Context()).Debug("Не удалось определить формат файла")
+		httpError(w, r, "Не удалось определить формат файла, укажите параметр format", http.StatusBadRequest)
+		return
+	}
+	format, err := transfer.ParseFormat(format)
+	if err != nil {
+		a.log(r.Context()).Debug(err.Error())
+		httpError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Файл сохраняется во временный, так как задача продолжает работу после ответа клиенту
+	tmp, err := os.CreateTemp("", "ads-import-*")
+	if err != nil {
+		a.log(r.Context()).Error("Ошибка при сохранении файла", err)
+		httpError(w, r, "Ошибка при сохранении файла", http.StatusInternalServerError)
+		return
+	}
+	cleanup := func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}
+
+	if _, err = io.Copy(tmp, body); err != nil {
+		cleanup()
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			a.log(r.Context()).Debug("Файл слишком большой")
+			httpError(w, r, "Файл слишком большой", http.StatusRequestEntityTooLarge)
+			return
+		}
+		a.log(r.Context()).Error("Ошибка при сохранении файла", err)
+		httpError(w, r, "Ошибка при сохранении файла", http.StatusInternalServerError)
+		return
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		a.log(r.Context()).Error("Ошибка при сохранении файла", err)
+		httpError(w, r, "Ошибка при сохранении файла", http.StatusInternalServerError)
+		return
+	}
+
+	// Ошибки заголовка CSV возвращаются сразу, не дожидаясь фоновой задачи
+	reader, err := transfer.NewReader(tmp, format)
+	if err != nil {
+		cleanup()
+		a.log(r.Context()).Debug("Ошибка в заголовке файла: %v", err)
+		httpError(w, r, "Ошибка в заголовке файла: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cfg := a.config()
+	importer := transfer.NewImporter(a.repo,
+		transfer.BatchSize(cfg.Api.BatchMaxItems),
+		transfer.MaxLifetime(cfg.Api.MaxLifetime),
+		transfer.KeepIDs(keepIDs),
+	)
+	job := a.jobs.Start("import", func(ctx context.Context, p *jobs.Progress) error {
+		defer cleanup()
+		return importer.Run(ctx, reader, p)
+	})
+	a.log(r.Context()).Info("Запущена загрузка объявлений, задача %s", job.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/admin/import/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(job); err != nil {
+		a.log(r.Context()).Error("не удалось сериализовать ответ JSON", err)
+	}
+}
+
+// @Summary Состояние загрузки объявлений
+// @Description Возвращает состояние фоновой задачи загрузки: количество обработанных строк и ошибки с номерами строк.
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Success 200 {object} jobs.Job
+// @Failure 404 {string} string "Задача не найдена"
+// @Router /admin/import/{id} [get]
+// @OperationId getImportJob
+func (a *api) getImportJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	job, ok := a.jobs.Get(id)
+	if !ok {
+		a.log(r.Context()).Debug("Задача %s не найдена", id)
+		httpError(w, r, "Задача не найдена", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		a.log(r.Context()).Error("не удалось сериализовать ответ JSON", err)
+	}
+}
+
+// nextFilePart Возвращает часть формы с полем file
+func nextFilePart(mr *multipart.Reader) (*multipart.Part, error) {
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+		_ = part.Close()
+	}
+}
+
+// @Summary Состояние индексов
+// @Description Сравнивает индексы коллекций с реестром индексов приложения.
+// @Description Состояния: ok - совпадает с реестром, missing - отсутствует, changed - отличается от реестра, unmanaged - отсутствует в реестре.
+// @Produce json
+// @Success 200 {array} models.IndexState
+// @Failure 500 {string} string "Ошибка при получении списка индексов"
+// @Router /admin/indexes [get]
+// @OperationId getIndexes
+func (a *api) getIndexes(w http.ResponseWriter, r *http.Request) {
+	states, err := a.indexes.IndexStates(r.Context())
+	if err != nil {
+		a.log(r.Context()).Error("Ошибка при получении списка индексов", err)
+		httpError(w, r, "Ошибка при получении списка индексов", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(states); err != nil {
+		a.log(r.Context()).Error("не удалось сериализовать ответ JSON", err)
+	}
+}
+
+// @Summary Действующая конфигурация
+// @Description Конфигурация с учётом перезагрузки в формате YAML, пароли и токены скрыты.
+// @Produce plain
+// @Success 200 {string} string "Конфигурация в формате YAML"
+// @Router /admin/config [get]
+// @OperationId getConfig
+func (a *api) getConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+	if err := a.config().WriteYAML(w); err != nil {
+		a.log(r.Context()).Error("не удалось вывести конфигурацию", err)
+	}
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"zatrasz75/Ads_service/models"
+)
+
+func Test_api_exportPosts_error(t *testing.T) {
+	ads := func(n int) []models.Ads {
+		list := make([]models.Ads, n)
+		for i := range list {
+			list[i] = models.Ads{ID: fmt.Sprintf("%024x", i+1), Name: "стол", Price: 100}
+		}
+		return list
+	}
+
+	tests := []struct {
+		name      string
+		ads       []models.Ads
+		wantAbort bool
+	}{
+		{name: "ошибка до начала выгрузки", ads: ads(1)},
+		{name: "ошибка после сброса части файла", ads: ads(exportFlushEvery + 1), wantAbort: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newBatchAPI(&memRepo{ads: tt.ads, streamErr: errors.New("курсор закрыт")})
+			rr := &headerCounter{ResponseRecorder: httptest.NewRecorder()}
+
+			var recovered interface{}
+			func() {
+				defer func() { recovered = recover() }()
+				a.exportPosts(rr, httptest.NewRequest(http.MethodGet, "/admin/export?format=csv", nil))
+			}()
+
+			if tt.wantAbort {
+				if recovered != http.ErrAbortHandler {
+					t.Errorf("ожидалась паника http.ErrAbortHandler, получено %v", recovered)
+				}
+				if rr.Code != http.StatusOK || rr.calls != 1 {
+					t.Errorf("код ответа %d отправлен %d раз, ожидался один код 200", rr.Code, rr.calls)
+				}
+				return
+			}
+			if recovered != nil {
+				t.Fatalf("неожиданная паника: %v", recovered)
+			}
+			if rr.Code != http.StatusInternalServerError || rr.calls != 1 {
+				t.Errorf("код ответа %d отправлен %d раз, ожидался один код 500", rr.Code, rr.calls)
+			}
+			if rr.Header().Get("Content-Disposition") != "" {
+				t.Error("ответ с ошибкой не должен предлагаться как файл")
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"zatrasz75/Ads_service/pkg/accesslog"
+)
+
+// adminUser Пользователь служебных маршрутов в журнале доступа
+const adminUser = "admin"
+
+// adminAuth Проверяет токен служебных маршрутов: заголовок Authorization: Bearer <токен>
+// или Basic с токеном в качестве пароля, чтобы служебные страницы открывались в браузере.
+// Проверки /healthz и /readyz доступны без токена. Без токена в конфигурации проверка отключена.
+func (a *api) adminAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := a.config().Admin.Token.Value()
+		if token == "" || r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			_, got, ok = r.BasicAuth()
+		}
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			a.log(r.Context()).Warn("Отказ в доступе к служебному маршруту")
+			w.Header().Set("WWW-Authenticate", `Basic realm="ads admin", charset="UTF-8"`)
+			httpError(w, r, "Требуется токен доступа", http.StatusUnauthorized)
+			return
+		}
+
+		accesslog.SetUser(r.Context(), adminUser)
+		next.ServeHTTP(w, r)
+	})
+}
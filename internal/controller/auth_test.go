@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+func Test_api_adminAuth(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.Admin.Token = "s3cret"
+	a := &api{Cfg: cfg, l: logger.Discard()}
+	h := a.adminAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		basic  string
+		want   int
+	}{
+		{name: "без токена", path: "/admin/config", want: http.StatusUnauthorized},
+		{name: "неверный токен", path: "/admin/config", header: "Bearer wrong", want: http.StatusUnauthorized},
+		{name: "Bearer", path: "/admin/config", header: "Bearer s3cret", want: http.StatusOK},
+		{name: "Basic", path: "/debug/pprof/", basic: "s3cret", want: http.StatusOK},
+		{name: "проверка готовности", path: "/readyz", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.basic != "" {
+				req.SetBasicAuth("ops", tt.basic)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("код %d, ожидался %d", rr.Code, tt.want)
+			}
+		})
+	}
+}
+
+func Test_adminRoutes(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.Admin.Token = "s3cret"
+	a := &api{Cfg: cfg, l: logger.Discard()}
+
+	public := mux.NewRouter()
+	newEndpoint(public, a)
+	admin := newMuxRouter(a.l, []mux.MiddlewareFunc{a.adminAuth})
+	newAdminEndpoint(admin, a)
+
+	for _, path := range []string{"/admin/export", "/admin/import", "/admin/config"} {
+		method := http.MethodGet
+		if path == "/admin/import" {
+			method = http.MethodPost
+		}
+
+		// Загрузка и выгрузка недоступны на публичном адресе
+		rr := httptest.NewRecorder()
+		public.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		if rr.Code != http.StatusNotFound && rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s на публичном адресе: код %d", method, path, rr.Code)
+		}
+
+		rr = httptest.NewRecorder()
+		admin.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s без токена: код %d, ожидался %d", method, path, rr.Code, http.StatusUnauthorized)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"zatrasz75/Ads_service/models"
+)
+
+// @Summary Пакетное создание объявлений
+// @Description Метод для добавления нескольких объявлений одним запросом.
+// @Description Каждое объявление проверяется отдельно: невалидные объявления не добавляются, остальные сохраняются.
+// @Description Возвращает результат по каждому объявлению в порядке запроса.
+// @Description Код 200 если все объявления добавлены, 207 при частичном успехе.
+// @Accept json
+// @Produce json
+// @Param ads body []models.Ads true "Список объявлений"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом вернёт сохранённый ответ"
+// @Success 200 {object} models.BatchResponse
+// @Success 207 {object} models.BatchResponse
+// @Failure 400 {string} string "не удалось проанализировать запрос JSON"
+// @Failure 400 {string} string "Пустой список объявлений"
+// @Failure 413 {string} string "Слишком много объявлений в одном запросе"
+// @Failure 413 {string} string "Слишком большое тело запроса"
+// @Failure 500 {string} string "Ошибка при добавлении данных"
+// @Router /posts/batch [post]
+// @OperationId addPostsBatch
+func (a *api) addPostsBatch(w http.ResponseWriter, r *http.Request) {
+	var items []models.Ads
+
+	// Размер тела ограничен в limitBody, поэтому массив не может занять больше batch-max-bytes
+	err := json.NewDecoder(r.Body).Decode(&items)
+	if tooLarge(err) {
+		a.log(r.Context()).Debug("Слишком большое тело запроса")
+		httpError(w, r, "Слишком большое тело запроса", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		httpError(w, r, "не удалось проанализировать запрос JSON", http.StatusBadRequest)
+		a.log(r.Context()).Error("не удалось проанализировать запрос JSON", err)
+		return
+	}
+	if len(items) == 0 {
+		a.log(r.Context()).Debug("Пустой список объявлений")
+		httpError(w, r, "Пустой список объявлений", http.StatusBadRequest)
+		return
+	}
+	if len(items) > a.config().Api.BatchMaxItems {
+		a.log(r.Context()).Debug("Слишком много объявлений в одном запросе: %d", len(items))
+		httpError(w, r, fmt.Sprintf("Слишком много объявлений в одном запросе, максимум %d", a.config().Api.BatchMaxItems), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	response := models.BatchResponse{Items: make([]models.BatchItemResult, len(items))}
+
+	// Проверка каждого объявления, валидные собираются для записи одним запросом
+	now := time.Now()
+	valid := make([]models.Ads, 0, len(items))
+	positions := make([]int, 0, len(items))
+	for i, p := range items {
+		response.Items[i].Index = i
+		if err = a.validateAd(p); err != nil {
+			response.Items[i].Error = err.Error()
+			continue
+		}
+		// ID назначает сервис, заданные клиентом ID и владелец не используются
+		p.ID = ""
+		p.Owner = ""
+		p.Creation = now
+		p.Price, err = models.RoundPrice(p.Price)
+		if err != nil {
+			response.Items[i].Error = "не удалось округлить цену"
+			continue
+		}
+		valid = append(valid, p)
+		positions = append(positions, i)
+	}
+
+	if len(valid) > 0 {
+		results, err := a.repo.AddPosts(r.Context(), valid)
+		if err != nil {
+			a.log(r.Context()).Error("Ошибка при добавлении данных", err)
+			httpError(w, r, "Ошибка при добавлении данных", http.StatusInternalServerError)
+			return
+		}
+		for j, res := range results {
+			res.Index = positions[j]
+			response.Items[positions[j]] = res
+		}
+	}
+
+	for _, item := range response.Items {
+		if item.Error != "" {
+			response.Failed++
+		} else {
+			response.Created++
+		}
+	}
+
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// Заголовок уже отправлен, второй ответ с ошибкой записать нельзя
+	if err = writeJSON(r.Context(), w, response); err != nil {
+		a.log(r.Context()).Error("не удалось сериализовать ответ JSON", err)
+	}
+}
+
+// batchMaxBytes Предельный размер тела пакетного запроса
+func (a *api) batchMaxBytes() int64 {
+	return a.config().Api.BatchMaxBytes
+}
+
+// @Summary Пакетное получение объявлений по списку ID
+// @Description Метод для получения нескольких объявлений за один запрос.
+// @Description ID передаются через запятую в параметре ids, ненайденные ID возвращаются в поле missing.
+// @Accept json
+// @Produce json
+// @Param ids query string true "ID объявлений через запятую"
+// @Success 200 {object} models.BatchGetResponse
+// @Failure 400 {string} string "Не удалось получить параметр ids"
+// @Failure 413 {string} string "Слишком много ID в одном запросе"
+// @Failure 500 {string} string "Ошибка при получении данных"
+// @Router /posts/batch [get]
+// @OperationId getPostsBatch
+func (a *api) getPostsBatch(w http.ResponseWriter, r *http.Request) {
+	// Разбор списка ID с удалением пустых значений и повторов. ID из шестнадцатеричных цифр
+	// сравниваются без учёта регистра, в missing возвращается ID в том виде, в каком он запрошен.
+	var ids, keys []string
+	seen := make(map[string]struct{})
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		key := strings.ToLower(id)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		ids = append(ids, id)
+		keys = append(keys, key)
+	}
+	if len(ids) == 0 {
+		a.log(r.Context()).Debug("Не удалось получить параметр ids")
+		httpError(w, r, "Не удалось получить параметр ids", http.StatusBadRequest)
+		return
+	}
+	if len(ids) > a.config().Api.BatchMaxItems {
+		a.log(r.Context()).Debug("Слишком много ID в одном запросе: %d", len(ids))
+		httpError(w, r, fmt.Sprintf("Слишком много ID в одном запросе, максимум %d", a.config().Api.BatchMaxItems), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	ads, err := a.repo.GetPostsByIDs(r.Context(), keys)
+	if err != nil {
+		a.log(r.Context()).Error("Ошибка при получении данных", err)
+		httpError(w, r, "Ошибка при получении данных", http.StatusInternalServerError)
+		return
+	}
+
+	// Упорядочивание результата в порядке запроса
+	found := make(map[string]models.Ads, len(ads))
+	for _, ad := range ads {
+		found[ad.ID] = ad
+	}
+	response := models.BatchGetResponse{
+		Items:   make([]models.Ads, 0, len(ads)),
+		Missing: []string{},
+	}
+	for i, id := range ids {
+		if ad, ok := found[keys[i]]; ok {
+			response.Items = append(response.Items, ad)
+		} else {
+			response.Missing = append(response.Missing, id)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	// Заголовок уже отправлен, второй ответ с ошибкой записать нельзя
+	if err = writeJSON(r.Context(), w, response); err != nil {
+		a.log(r.Context()).Error("Ошибка при сериализации ответа JSON", err)
+	}
+}
@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/models"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// memRepo Хранилище объявлений в памяти для тестов обработчиков
+type memRepo struct {
+	ads []models.Ads
+	// streamErr Ошибка, которую StreamPosts и ExportPosts возвращают после передачи всех объявлений
+	streamErr error
+}
+
+func (m *memRepo) GetListPost(ctx context.Context, page int, sortField, sortOrder string) ([]models.Ads, error) {
+	return m.FindPosts(ctx, page, models.ListFilter{SortField: sortField, SortOrder: sortOrder})
+}
+
+func (m *memRepo) FindPosts(context.Context, int, models.ListFilter) ([]models.Ads, error) {
+	return m.ads, nil
+}
+
+func (m *memRepo) StreamPosts(_ context.Context, _ int, _ models.ListFilter, fn func(models.Ads) error) error {
+	for _, ad := range m.ads {
+		if err := fn(ad); err != nil {
+			return err
+		}
+	}
+	return m.streamErr
+}
+
+func (m *memRepo) GetSpecificPost(_ context.Context, id string) (models.Ads, error) {
+	for _, ad := range m.ads {
+		if ad.ID == id {
+			return ad, nil
+		}
+	}
+	return models.Ads{}, fmt.Errorf("объявление %s не найдено", id)
+}
+
+func (m *memRepo) AddPost(_ context.Context, ad models.Ads) (string, error) {
+	if ad.ID == "" {
+		ad.ID = fmt.Sprintf("%024x", len(m.ads)+1)
+	}
+	m.ads = append(m.ads, ad)
+	return ad.ID, nil
+}
+
+func (m *memRepo) AddPosts(ctx context.Context, ads []models.Ads) ([]models.BatchItemResult, error) {
+	results := make([]models.BatchItemResult, len(ads))
+	for i, ad := range ads {
+		id, err := m.AddPost(ctx, ad)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = models.BatchItemResult{Index: i, ID: id}
+	}
+	return results, nil
+}
+
+func (m *memRepo) GetPostsByIDs(ctx context.Context, ids []string) ([]models.Ads, error) {
+	var found []models.Ads
+	for _, id := range ids {
+		if ad, err := m.GetSpecificPost(ctx, id); err == nil {
+			found = append(found, ad)
+		}
+	}
+	return found, nil
+}
+
+func (m *memRepo) ExportPosts(ctx context.Context, filter models.ListFilter, fn func(models.Ads) error) error {
+	return m.StreamPosts(ctx, 0, filter, fn)
+}
+
+func (m *memRepo) SearchPosts(context.Context, string, int) ([]models.SearchResult, error) {
+	return nil, nil
+}
+
+func newBatchAPI(repo *memRepo) *api {
+	cfg := &configs.Config{}
+	cfg.Api.BatchMaxItems = 3
+	cfg.Api.BatchMaxBytes = 1024
+	return &api{Cfg: cfg, l: logger.Discard(), repo: repo}
+}
+
+func Test_api_addPostsBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantCode    int
+		wantCreated int
+		wantFailed  int
+	}{
+		{name: "все добавлены", body: `[{"name":"стол","price":100},{"name":"стул","price":50}]`, wantCode: http.StatusOK, wantCreated: 2},
+		{name: "частичный успех", body: `[{"name":"стол","price":100},{"name":"","price":50},{"name":"шкаф","price":10,"currency":"rub"}]`, wantCode: http.StatusMultiStatus, wantCreated: 1, wantFailed: 2},
+		{name: "пустой список", body: `[]`, wantCode: http.StatusBadRequest},
+		{name: "больше batch-max-items", body: `[{"name":"a","price":1},{"name":"b","price":1},{"name":"c","price":1},{"name":"d","price":1}]`, wantCode: http.StatusRequestEntityTooLarge},
+		{name: "больше batch-max-bytes", body: `[{"name":"` + strings.Repeat("a", 2048) + `","price":1}]`, wantCode: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memRepo{}
+			a := newBatchAPI(repo)
+			h := a.limitBody(a.batchMaxBytes, a.addPostsBatch)
+
+			rr := httptest.NewRecorder()
+			h(rr, httptest.NewRequest(http.MethodPost, "/posts/batch", strings.NewReader(tt.body)))
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("Получили code: %v Ожидали %v: %s", rr.Code, tt.wantCode, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK && rr.Code != http.StatusMultiStatus {
+				if len(repo.ads) != 0 {
+					t.Errorf("добавлено %d объявлений, ожидалось 0", len(repo.ads))
+				}
+				return
+			}
+
+			var resp models.BatchResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Created != tt.wantCreated || resp.Failed != tt.wantFailed || len(repo.ads) != tt.wantCreated {
+				t.Errorf("создано %d, ошибок %d, в хранилище %d, ожидалось %d и %d", resp.Created, resp.Failed, len(repo.ads), tt.wantCreated, tt.wantFailed)
+			}
+			for i, item := range resp.Items {
+				if item.Index != i || (item.ID == "") == (item.Error == "") {
+					t.Errorf("элемент %d = %+v: ожидался либо ID, либо ошибка", i, item)
+				}
+			}
+		})
+	}
+}
+
+func Test_api_getPostsBatch(t *testing.T) {
+	repo := &memRepo{ads: []models.Ads{
+		{ID: "65f000000000000000000001", Name: "стол"},
+		{ID: "65f000000000000000000002", Name: "стул"},
+	}}
+
+	tests := []struct {
+		name        string
+		ids         string
+		wantCode    int
+		wantItems   []string
+		wantMissing []string
+	}{
+		{name: "в порядке запроса", ids: "65f000000000000000000002,65f000000000000000000001", wantCode: http.StatusOK, wantItems: []string{"стул", "стол"}, wantMissing: []string{}},
+		{name: "некорректные и повторные ID", ids: "не-id, 65f000000000000000000001,,65f000000000000000000001,65f0000000000000000000ff", wantCode: http.StatusOK, wantItems: []string{"стол"}, wantMissing: []string{"не-id", "65f0000000000000000000ff"}},
+		{name: "ID в верхнем регистре", ids: "65F000000000000000000002,65f000000000000000000002,65F0000000000000000000FF", wantCode: http.StatusOK, wantItems: []string{"стул"}, wantMissing: []string{"65F0000000000000000000FF"}},
+		{name: "без ids", ids: " , ", wantCode: http.StatusBadRequest},
+		{name: "больше batch-max-items", ids: "1,2,3,4", wantCode: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newBatchAPI(repo)
+			req := httptest.NewRequest(http.MethodGet, "/posts/batch?ids="+strings.ReplaceAll(tt.ids, " ", "%20"), nil)
+			rr := httptest.NewRecorder()
+			a.getPostsBatch(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("Получили code: %v Ожидали %v: %s", rr.Code, tt.wantCode, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var resp models.BatchGetResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, ad := range resp.Items {
+				names = append(names, ad.Name)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.wantItems) || fmt.Sprint(resp.Missing) != fmt.Sprint(tt.wantMissing) {
+				t.Errorf("получено %v, missing %v, ожидалось %v, missing %v", names, resp.Missing, tt.wantItems, tt.wantMissing)
+			}
+		})
+	}
+}
+
+// brokenWriter Соединение, оборванное клиентом: заголовок отправляется, запись тела завершается ошибкой
+type brokenWriter struct {
+	*headerCounter
+}
+
+func (b brokenWriter) Write([]byte) (int, error) {
+	return 0, errors.New("соединение закрыто")
+}
+
+func Test_api_batch_writeError(t *testing.T) {
+	repo := &memRepo{ads: []models.Ads{{ID: "65f000000000000000000001", Name: "стол"}}}
+	tests := []struct {
+		name    string
+		req     *http.Request
+		handler func(a *api) http.HandlerFunc
+	}{
+		{
+			name:    "получение",
+			req:     httptest.NewRequest(http.MethodGet, "/posts/batch?ids=65f000000000000000000001", nil),
+			handler: func(a *api) http.HandlerFunc { return a.getPostsBatch },
+		},
+		{
+			name:    "добавление",
+			req:     httptest.NewRequest(http.MethodPost, "/posts/batch", strings.NewReader(`[{"name":"стул","description":"деревянный","price":10}]`)),
+			handler: func(a *api) http.HandlerFunc { return a.addPostsBatch },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := brokenWriter{&headerCounter{ResponseRecorder: httptest.NewRecorder()}}
+			tt.handler(newBatchAPI(repo))(rr, tt.req)
+
+			// Ошибка записи только журналируется: повторный WriteHeader с кодом 500 не отправляется
+			if rr.calls != 1 || rr.Code != http.StatusOK {
+				t.Errorf("WriteHeader вызван %d раз, код %d", rr.calls, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"zatrasz75/Ads_service/pkg/health"
+)
+
+// @Summary Проверка работы процесса
+// @Description Отвечает 200, пока процесс запущен и обрабатывает запросы. Зависимости не проверяются.
+// @Produce plain
+// @Success 200 {string} string "ok"
+// @Router /healthz [get]
+// @OperationId healthz
+func (a *api) healthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok"))
+}
+
+// @Summary Проверка готовности
+// @Description Отвечает 200, если MongoDB доступна, все миграции применены и сервис не завершает работу,
+// @Description иначе 503 со списком непройденных проверок. Неприменённые миграции перечисляются по версии и описанию:
+// @Description без MIGRATIONS_AUTO сервис не готов, пока не выполнена команда migrate up.
+// @Produce plain
+// @Success 200 {string} string "ok"
+// @Failure 503 {string} string "Непройденные проверки"
+// @Router /readyz [get]
+// @OperationId readyz
+func (a *api) readyz(w http.ResponseWriter, r *http.Request) {
+	report := a.health.Check(r.Context())
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if report.Up() {
+		_, _ = w.Write([]byte("ok"))
+		return
+	}
+
+	var failed []string
+	for name, res := range report.Checks {
+		if res.Status != health.StatusUp {
+			failed = append(failed, name+": "+res.Error)
+		}
+	}
+	sort.Strings(failed)
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.Write([]byte(strings.Join(failed, "\n")))
+}
+
+// @Summary Состояние зависимостей
+// @Description Результат каждой проверки готовности с длительностью в миллисекундах.
+// @Description Код ответа 200, если все проверки пройдены, иначе 503.
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /health [get]
+// @OperationId health
+func (a *api) healthReport(w http.ResponseWriter, r *http.Request) {
+	report := a.health.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Up() {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		a.log(r.Context()).Error("не удалось сериализовать ответ JSON", err)
+	}
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"zatrasz75/Ads_service/pkg/health"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+func Test_api_readyz(t *testing.T) {
+	var dbErr error
+	checker := health.New()
+	checker.Add("mongo", func(context.Context) error { return dbErr })
+	a := &api{l: logger.Discard(), health: checker}
+
+	rr := httptest.NewRecorder()
+	a.readyz(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("код %d, ожидался 200", rr.Code)
+	}
+
+	dbErr = errors.New("нет соединения")
+	rr = httptest.NewRecorder()
+	a.readyz(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), "mongo: нет соединения") {
+		t.Errorf("код %d, тело %q", rr.Code, rr.Body.String())
+	}
+
+	// Процесс жив, даже если зависимости недоступны
+	rr = httptest.NewRecorder()
+	a.healthz(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("healthz: код %d, ожидался 200", rr.Code)
+	}
+}
+
+func Test_api_healthReport(t *testing.T) {
+	checker := health.New()
+	checker.Add("mongo", func(context.Context) error { return nil })
+	checker.Drain()
+	a := &api{l: logger.Discard(), health: checker}
+
+	rr := httptest.NewRecorder()
+	a.healthReport(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("код %d, ожидался 503 после Drain", rr.Code)
+	}
+
+	var report health.Report
+	if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Checks["mongo"].Status != health.StatusUp || report.Checks["shutdown"].Status != health.StatusDown {
+		t.Errorf("отчёт %+v", report)
+	}
+}
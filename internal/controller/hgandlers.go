@@ -1,51 +1,115 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
+	"io"
 	"net/http"
+	"net/http/pprof"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 	"zatrasz75/Ads_service/configs"
 	_ "zatrasz75/Ads_service/docs"
+	"zatrasz75/Ads_service/internal/jobs"
 	"zatrasz75/Ads_service/internal/repository"
 	"zatrasz75/Ads_service/internal/storage"
 	"zatrasz75/Ads_service/models"
+	"zatrasz75/Ads_service/pkg/health"
 	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/requestid"
+	"zatrasz75/Ads_service/pkg/tracing"
 )
 
 type api struct {
-	Cfg  *configs.Config
-	l    logger.LoggersInterface
-	repo storage.RepositoryInterface
+	Cfg     *configs.Config
+	l       logger.LoggersInterface
+	repo    storage.RepositoryInterface
+	idem    storage.IdempotencyInterface
+	indexes storage.IndexInterface
+	jobs    *jobs.Registry
+	health  *health.Checker
+	// live Конфигурация после перезагрузки, nil пока конфигурация не менялась
+	live atomic.Pointer[configs.Config]
 }
 
-func newEndpoint(r *mux.Router, cfg *configs.Config, l logger.LoggersInterface, repo *repository.Store) {
-	en := &api{cfg, l, repo}
-	r.HandleFunc("/posts/list", en.getListPost).Methods(http.MethodGet)
-	r.HandleFunc("/posts", en.getSpecificPost).Methods(http.MethodGet)
-	r.HandleFunc("/posts", en.addPost).Methods(http.MethodPost)
+// config Возвращает действующую конфигурацию с учётом перезагрузки
+func (a *api) config() *configs.Config {
+	if cfg := a.live.Load(); cfg != nil {
+		return cfg
+	}
+	return a.Cfg
+}
+
+func newAPI(cfg *configs.Config, l logger.LoggersInterface, repo *repository.Store, reloader *configs.Reloader, checker *health.Checker, registry *jobs.Registry) *api {
+	en := &api{Cfg: cfg, l: l, repo: repo, idem: repo, indexes: repo, jobs: registry, health: checker}
+	if reloader != nil {
+		reloader.Subscribe(en.live.Store)
+	}
+	return en
+}
 
-	r.HandleFunc("/", en.home).Methods(http.MethodGet)
+// newEndpoint Публичный API объявлений
+func newEndpoint(r *mux.Router, en *api) {
+	r.HandleFunc("/posts/list", traced("getListPost", en.getListPost)).Methods(http.MethodGet)
+	r.HandleFunc("/posts/search", traced("searchPosts", en.searchPosts)).Methods(http.MethodGet)
+	r.HandleFunc("/posts", traced("getSpecificPost", en.getSpecificPost)).Methods(http.MethodGet)
+	r.HandleFunc("/posts", traced("addPost", en.limitBody(en.maxBodyBytes, en.idempotent(en.addPost)))).Methods(http.MethodPost)
+	r.HandleFunc("/posts/batch", traced("getPostsBatch", en.getPostsBatch)).Methods(http.MethodGet)
+	r.HandleFunc("/posts/batch", traced("addPostsBatch", en.limitBody(en.batchMaxBytes, en.idempotent(en.addPostsBatch)))).Methods(http.MethodPost)
+}
+
+// newAdminEndpoint Служебные маршруты: загрузка и выгрузка, индексы, конфигурация, проверки, документация и профилирование
+func newAdminEndpoint(r *mux.Router, en *api) {
+	r.HandleFunc("/admin/export", traced("exportPosts", en.exportPosts)).Methods(http.MethodGet)
+	r.HandleFunc("/admin/import", traced("importPosts", en.importPosts)).Methods(http.MethodPost)
+	r.HandleFunc("/admin/import/{id}", traced("getImportJob", en.getImportJob)).Methods(http.MethodGet)
+	r.HandleFunc("/admin/indexes", traced("getIndexes", en.getIndexes)).Methods(http.MethodGet)
+	r.HandleFunc("/admin/config", traced("getConfig", en.getConfig)).Methods(http.MethodGet)
+
+	r.HandleFunc("/healthz", en.healthz).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", en.readyz).Methods(http.MethodGet)
+	r.HandleFunc("/health", en.healthReport).Methods(http.MethodGet)
+
+	r.HandleFunc("/", traced("home", en.home)).Methods(http.MethodGet)
 
 	// Swagger UI
 	r.PathPrefix("/docs/").Handler(http.StripPrefix("/docs/", http.FileServer(http.Dir("./docs/"))))
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+
+	if en.Cfg.Admin.Pprof {
+		r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		r.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		// Index отдаёт и именованные профили: /debug/pprof/heap, /debug/pprof/goroutine
+		r.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+	}
 }
 
 // @Summary Получение списка объявлений
 // @Description Метод для получения списка объявлений с возможностью сортировки по цене или дате создания, а также пагинации.
 // @Description Возвращает список объявлений с указанными параметрами сортировки и пагинации.
+// @Description С параметром near возвращаются объявления рядом с точкой (в радиусе radiusKm, если задан) и расстояние до каждого из них,
+// @Description по умолчанию объявления упорядочены по возрастанию расстояния, доступна сортировка sortField=distance.
+// @Description Пустая страница возвращается как пустой массив [], а не null.
+// @Description Список отправляется по мере чтения из базы: если ошибка возникла после начала ответа, соединение разрывается.
 // @Accept json
 // @Produce json
 // @Param page query int false "Номер страницы для пагинации (по умолчанию 1)"
-// @Param sortField query string false "Поле для сортировки (например, creation или price)"
+// @Param sortField query string false "Поле для сортировки (например, creation, price или distance)"
 // @Param sortOrder query string false "Порядок сортировки (asc или desc)"
-// @Success 200 {array} models.Response
+// @Param near query string false "Точка поиска в формате lat,lon (например, 55.75,37.61)"
+// @Param radiusKm query number false "Радиус поиска в километрах, используется вместе с near"
+// @Success 200 {array} listItem
+// @Failure 400 {string} string "Некорректные параметры запроса"
 // @Failure 500 {string} string "Ошибка при получении списка объявлений"
-// @Failure 500 {string} string "Ошибка при сериализации списка объявлений в JSON"
 // @Router /posts/list [get]
 // @OperationId getListPost
 func (a *api) getListPost(w http.ResponseWriter, r *http.Request) {
@@ -56,47 +120,94 @@ func (a *api) getListPost(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		page = 1
 	}
-	sortField := queryParams.Get("sortField")
-	sortOrder := queryParams.Get("sortOrder")
 
-	ads, err := a.repo.GetListPost(page, sortField, sortOrder)
+	filter, err := parseListFilter(queryParams)
 	if err != nil {
-		http.Error(w, "Ошибка при получении списка объявлений", http.StatusInternalServerError)
-		a.l.Error("Ошибка при получении списка объявлений", err)
+		a.log(r.Context()).Debug(err.Error())
+		httpError(w, r, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Создание среза для хранения только необходимых полей
-	var response []struct {
-		Name  string  `json:"name"`
-		Price float64 `json:"price"`
+	// Объявления отправляются по мере чтения из курсора, страница не собирается в памяти целиком
+	list := newJSONArray(w)
+	err = a.repo.StreamPosts(r.Context(), page, filter, func(ad models.Ads) error {
+		return list.Write(listItem{
+			Name:       ad.Name,
+			Price:      ad.Price,
+			DistanceKm: ad.DistanceKm,
+		})
+	})
+	if err != nil {
+		a.log(r.Context()).Error("Ошибка при получении списка объявлений", err)
+		if !list.Started() {
+			httpError(w, r, "Ошибка при получении списка объявлений", http.StatusInternalServerError)
+			return
+		}
+		// Начало списка уже отправлено с кодом 200, обрыв соединения не даст принять неполный список за целый
+		panic(http.ErrAbortHandler)
 	}
+	if err = list.Close(); err != nil {
+		a.log(r.Context()).Error("Ошибка при отправке списка объявлений", err)
+	}
+}
 
-	// Заполнение среза данными из ads
-	for _, ad := range ads {
-		response = append(response, struct {
-			Name  string  `json:"name"`
-			Price float64 `json:"price"`
-		}{
-			Name:  ad.Name,
-			Price: ad.Price,
-		})
+// listItem Объявление в списке: только название, цена и расстояние при поиске рядом с точкой
+type listItem struct {
+	Name       string   `json:"name"`
+	Price      float64  `json:"price"`
+	DistanceKm *float64 `json:"distanceKm,omitempty"`
+}
+
+// parseListFilter Разбирает параметры сортировки и поиска по местоположению
+func parseListFilter(queryParams url.Values) (models.ListFilter, error) {
+	filter := models.ListFilter{
+		SortField: queryParams.Get("sortField"),
+		SortOrder: queryParams.Get("sortOrder"),
+	}
+	if filter.SortField != "" && filter.SortOrder == "" {
+		filter.SortOrder = "asc"
+	}
+	if filter.SortField != "" && filter.SortOrder != "asc" && filter.SortOrder != "desc" {
+		return models.ListFilter{}, fmt.Errorf("некорректное значение sortOrder: %s", filter.SortOrder)
 	}
 
-	// Установка заголовка Content-Type для ответа
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(response)
-	if err != nil {
-		http.Error(w, "Ошибка при сериализации списка объявлений в JSON", http.StatusInternalServerError)
-		a.l.Error("Ошибка при сериализации списка объявлений в JSON", err)
-		return
+	near := queryParams.Get("near")
+	radius := queryParams.Get("radiusKm")
+	if near == "" {
+		if radius != "" {
+			return models.ListFilter{}, errors.New("параметр radiusKm используется только вместе с near")
+		}
+		if filter.SortField == "distance" {
+			return models.ListFilter{}, errors.New("сортировка по расстоянию возможна только с параметром near")
+		}
+		return filter, nil
+	}
+
+	parts := strings.Split(near, ",")
+	if len(parts) != 2 {
+		return models.ListFilter{}, errors.New("параметр near должен быть в формате lat,lon")
+	}
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lon, errLon := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if errLat != nil || errLon != nil || !(models.Location{Lat: lat, Lon: lon}).Valid() {
+		return models.ListFilter{}, errors.New("некорректные координаты в параметре near")
 	}
+	filter.Near = &models.GeoFilter{Lat: lat, Lon: lon}
+
+	if radius != "" {
+		var err error
+		filter.Near.RadiusKm, err = strconv.ParseFloat(radius, 64)
+		if err != nil || filter.Near.RadiusKm <= 0 {
+			return models.ListFilter{}, errors.New("параметр radiusKm должен быть положительным числом")
+		}
+	}
+
+	return filter, nil
 }
 
 // @Summary Получение конкретного объявления по ID
 // @Description Метод для получения информации о конкретном объявлении по его уникальному идентификатору.
-// @Description Возвращает данные объявления, включая название, описание и цену.
+// @Description Возвращает данные объявления, включая название, описание, цену и местоположение, если оно указано.
 // @Description Если поля название объявления или цена отсутствуют возвращает ошибку 400
 // @Description Если запрошен параметр "fields" со значением "description", возвращает также описание объявления.
 // @Accept json
@@ -113,22 +224,22 @@ func (a *api) getSpecificPost(w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
 	idStr := queryParams.Get("id")
 	if idStr == "" {
-		a.l.Debug("Не удалось получить параметр id")
-		http.Error(w, "Не удалось получить параметр id", http.StatusBadRequest)
+		a.log(r.Context()).Debug("Не удалось получить параметр id")
+		httpError(w, r, "Не удалось получить параметр id", http.StatusBadRequest)
 		return
 	}
 
-	ads, err := a.repo.GetSpecificPost(idStr)
+	ads, err := a.repo.GetSpecificPost(r.Context(), idStr)
 	if err != nil {
-		a.l.Error("Ошибка при получении данных", err)
-		http.Error(w, "Ошибка при получении данных", http.StatusInternalServerError)
+		a.log(r.Context()).Error("Ошибка при получении данных", err)
+		httpError(w, r, "Ошибка при получении данных", http.StatusInternalServerError)
 		return
 	}
 
 	// Проверка наличия обязательных полей
 	if ads.Name == "" || ads.Price == 0 {
-		a.l.Debug("Обязательные поля объявления отсутствуют")
-		http.Error(w, "Обязательные поля объявления отсутствуют", http.StatusBadRequest)
+		a.log(r.Context()).Debug("Обязательные поля объявления отсутствуют")
+		httpError(w, r, "Обязательные поля объявления отсутствуют", http.StatusBadRequest)
 		return
 	}
 
@@ -136,37 +247,41 @@ func (a *api) getSpecificPost(w http.ResponseWriter, r *http.Request) {
 	fields := queryParams.Get("fields")
 	if fields == "description" {
 		response := struct {
-			Name        string  `json:"name"`
-			Description string  `json:"description"`
-			Price       float64 `json:"price"`
+			Name        string           `json:"name"`
+			Description string           `json:"description"`
+			Price       float64          `json:"price"`
+			Location    *models.Location `json:"location,omitempty"`
 		}{
 			Name:        ads.Name,
 			Description: ads.Description,
 			Price:       ads.Price,
+			Location:    ads.Location,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(response)
+		err = writeJSON(r.Context(), w, response)
 		if err != nil {
-			a.l.Error("Ошибка при сериализации ответа JSON", err)
-			http.Error(w, "Ошибка при сериализации ответа JSON", http.StatusInternalServerError)
+			a.log(r.Context()).Error("Ошибка при сериализации ответа JSON", err)
+			httpError(w, r, "Ошибка при сериализации ответа JSON", http.StatusInternalServerError)
 			return
 		}
 	} else {
 		// Если не запрошено описание, возвращаем название и цену
 		response := struct {
-			Name  string  `json:"name"`
-			Price float64 `json:"price"`
+			Name     string           `json:"name"`
+			Price    float64          `json:"price"`
+			Location *models.Location `json:"location,omitempty"`
 		}{
-			Name:  ads.Name,
-			Price: ads.Price,
+			Name:     ads.Name,
+			Price:    ads.Price,
+			Location: ads.Location,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(response)
+		err = writeJSON(r.Context(), w, response)
 		if err != nil {
-			a.l.Error("Ошибка при сериализации ответа JSON", err)
-			http.Error(w, "Ошибка при сериализации ответа JSON", http.StatusInternalServerError)
+			a.log(r.Context()).Error("Ошибка при сериализации ответа JSON", err)
+			httpError(w, r, "Ошибка при сериализации ответа JSON", http.StatusInternalServerError)
 			return
 		}
 	}
@@ -175,15 +290,21 @@ func (a *api) getSpecificPost(w http.ResponseWriter, r *http.Request) {
 
 // @Summary Создание нового объявления
 // @Description Метод для добавления нового объявления в систему.
-// @Description Принимает поля: название, описание, цена (name , description , price).
+// @Description Принимает поля: название, описание, цена (name , description , price) и необязательное местоположение location (lat, lon, city, address).
+// @Description Необязательное поле expiresAt задаёт время, после которого объявление будет удалено.
+// @Description Необязательное поле status принимает значения active, sold или archived, поле owner игнорируется.
 // @Description Обязательные поля: название и цена (name и price).
 // @Description Возвращает ID созданного объявления и код результата (ошибка или успех).
 // @Accept json
 // @Produce json
 // @Param ads body models.Ads true "Объявление"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом вернёт сохранённый ответ"
 // @Success 200 {object} models.Response
 // @Failure 400 {string} string "не удалось проанализировать запрос JSON"
 // @Failure 400 {string} string "Обязательные поля name или price объявления отсутствуют"
+// @Failure 409 {string} string "Запрос с этим ключом идемпотентности ещё выполняется"
+// @Failure 422 {string} string "Ключ идемпотентности уже использован с другим запросом"
+// @Failure 413 {string} string "Слишком большое тело запроса"
 // @Failure 500 {string} string "не удалось округлить цену"
 // @Failure 500 {string} string "Ошибка при добавлении данных"
 // @Failure 500 {string} string "не удалось сериализовать ответ JSON"
@@ -193,32 +314,37 @@ func (a *api) addPost(w http.ResponseWriter, r *http.Request) {
 	var p models.Ads
 
 	err := json.NewDecoder(r.Body).Decode(&p)
+	if tooLarge(err) {
+		a.log(r.Context()).Debug("Слишком большое тело запроса")
+		httpError(w, r, "Слишком большое тело запроса", http.StatusRequestEntityTooLarge)
+		return
+	}
 	if err != nil {
-		http.Error(w, "не удалось проанализировать запрос JSON", http.StatusBadRequest)
-		a.l.Error("не удалось проанализировать запрос JSON", err)
+		httpError(w, r, "не удалось проанализировать запрос JSON", http.StatusBadRequest)
+		a.log(r.Context()).Error("не удалось проанализировать запрос JSON", err)
 		return
 	}
-	if p.Name == "" || p.Price == 0 {
-		a.l.Debug("Обязательные поля name или price объявления отсутствуют")
-		http.Error(w, "Обязательные поля name или price объявления отсутствуют", http.StatusBadRequest)
+	if err = a.validateAd(p); err != nil {
+		a.log(r.Context()).Debug(err.Error())
+		httpError(w, r, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// Владелец не принимается от клиента, он задаётся только при загрузке через /admin/import
+	p.Owner = ""
 	p.Creation = time.Now()
 
 	// Округление Price до двух знаков после запятой
-	roundedPriceStr := fmt.Sprintf("%.2f", p.Price)
-	roundedPrice, err := strconv.ParseFloat(roundedPriceStr, 64)
+	p.Price, err = models.RoundPrice(p.Price)
 	if err != nil {
-		http.Error(w, "не удалось округлить цену", http.StatusInternalServerError)
-		a.l.Error("не удалось округлить цену", err)
+		httpError(w, r, "не удалось округлить цену", http.StatusInternalServerError)
+		a.log(r.Context()).Error("не удалось округлить цену", err)
 		return
 	}
-	p.Price = roundedPrice
 
-	id, err := a.repo.AddPost(p)
+	id, err := a.repo.AddPost(r.Context(), p)
 	if err != nil {
-		a.l.Error("Ошибка при добавлении данных", err)
-		http.Error(w, "Ошибка при добавлении данных", http.StatusInternalServerError)
+		a.log(r.Context()).Error("Ошибка при добавлении данных", err)
+		httpError(w, r, "Ошибка при добавлении данных", http.StatusInternalServerError)
 		return
 	}
 	response := models.Response{
@@ -229,15 +355,20 @@ func (a *api) addPost(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	// Сериализация структуры ответа в JSON и запись в http.ResponseWriter
-	err = json.NewEncoder(w).Encode(response)
+	err = writeJSON(r.Context(), w, response)
 	if err != nil {
-		http.Error(w, "не удалось сериализовать ответ JSON", http.StatusInternalServerError)
-		a.l.Error("не удалось сериализовать ответ JSON", err)
+		httpError(w, r, "не удалось сериализовать ответ JSON", http.StatusInternalServerError)
+		a.log(r.Context()).Error("не удалось сериализовать ответ JSON", err)
 		return
 	}
 }
 
-func (a *api) home(w http.ResponseWriter, _ *http.Request) {
+// validateAd Проверяет объявление с ограничением срока действия api.max-lifetime
+func (a *api) validateAd(p models.Ads) error {
+	return p.Validate(a.config().Api.MaxLifetime)
+}
+
+func (a *api) home(w http.ResponseWriter, r *http.Request) {
 	// Устанавливаем правильный Content-Type для HTML
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
@@ -246,7 +377,105 @@ func (a *api) home(w http.ResponseWriter, _ *http.Request) {
 
 	_, err := fmt.Fprintf(w, "<p>%s</p>", str)
 	if err != nil {
-		http.Error(w, "Ошибка записи на страницу", http.StatusInternalServerError)
-		a.l.Error("Ошибка записи на страницу", err)
+		httpError(w, r, "Ошибка записи на страницу", http.StatusInternalServerError)
+		a.log(r.Context()).Error("Ошибка записи на страницу", err)
+	}
+}
+
+// log Возвращает журнал запроса из ctx с идентификатором трассировки
+func (a *api) log(ctx context.Context) logger.LoggersInterface {
+	return tracing.Logger(ctx, logger.FromContext(ctx, a.l))
+}
+
+// httpError Отвечает текстом ошибки как http.Error, добавляя идентификатор запроса,
+// по которому ошибку можно найти в журнале
+func httpError(w http.ResponseWriter, r *http.Request, message string, code int) {
+	if id := requestid.FromContext(r.Context()); id != "" {
+		message += "\nrequest_id: " + id
+	}
+	http.Error(w, message, code)
+}
+
+// limitBody Ограничивает размер тела запроса, лимит читается из действующей конфигурации при каждом запросе
+func (a *api) limitBody(limit func() int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, limit())
+		next(w, r)
+	}
+}
+
+// maxBodyBytes Предельный размер тела запроса на создание объявления
+func (a *api) maxBodyBytes() int64 {
+	return a.config().Api.MaxBodyBytes
+}
+
+// tooLarge Сообщает, что чтение тела прервано из-за превышения лимита http.MaxBytesReader
+func tooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// traced Выполняет обработчик в отдельной операции трассировки handler.<name>,
+// вложенной в операцию запроса из tracing.Middleware
+func traced(name string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.Start(r.Context(), "handler."+name)
+		defer span.End()
+		h(w, r.WithContext(ctx))
+	}
+}
+
+// writeJSON Сериализует v в ответ, сериализация учитывается отдельной операцией трассировки
+func writeJSON(ctx context.Context, w io.Writer, v interface{}) error {
+	_, span := tracing.Start(ctx, "json.encode")
+	err := json.NewEncoder(w).Encode(v)
+	tracing.End(span, err)
+	return err
+}
+
+// jsonArray Отправляет JSON массив по одному элементу. Заголовки и код 200 отправляются вместе с первым элементом,
+// поэтому до него ещё можно ответить ошибкой.
+type jsonArray struct {
+	w     http.ResponseWriter
+	enc   *json.Encoder
+	count int
+}
+
+func newJSONArray(w http.ResponseWriter) *jsonArray {
+	return &jsonArray{w: w, enc: json.NewEncoder(w)}
+}
+
+// Write Добавляет элемент в массив
+func (a *jsonArray) Write(v interface{}) error {
+	sep := ","
+	if a.count == 0 {
+		a.start()
+		sep = "["
+	}
+	if _, err := io.WriteString(a.w, sep); err != nil {
+		return err
 	}
+	a.count++
+	return a.enc.Encode(v)
+}
+
+// Started Сообщает, отправлено ли начало массива
+func (a *jsonArray) Started() bool {
+	return a.count > 0
+}
+
+// Close Завершает массив, пустой список отправляется как []
+func (a *jsonArray) Close() error {
+	if a.count == 0 {
+		a.start()
+		_, err := io.WriteString(a.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(a.w, "]\n")
+	return err
+}
+
+func (a *jsonArray) start() {
+	a.w.Header().Set("Content-Type", "application/json")
+	a.w.WriteHeader(http.StatusOK)
 }
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -9,14 +10,18 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 	"zatrasz75/Ads_service/configs"
 	"zatrasz75/Ads_service/internal/repository"
+	"zatrasz75/Ads_service/models"
+	"zatrasz75/Ads_service/pkg/compress"
 	"zatrasz75/Ads_service/pkg/logger"
 	"zatrasz75/Ads_service/pkg/mongo"
+	"zatrasz75/Ads_service/pkg/recovery"
 )
 
 func Test_api_addPost_getSpecificPost(t *testing.T) {
-	l := logger.NewLogger()
+	l := logger.Discard()
 
 	// Получаем текущий рабочий каталог
 	cwd, err := os.Getwd()
@@ -32,7 +37,7 @@ func Test_api_addPost_getSpecificPost(t *testing.T) {
 	if err != nil {
 		l.Fatal("ошибка при разборе конфигурационного файла", err)
 	}
-	mg, err := mongo.New(cfg.Mongo.ConnStr, l, mongo.OptionSet(cfg.Mongo.ConnAttempts, cfg.Mongo.ConnTimeout, cfg.Mongo.DbName, cfg.Mongo.CollectionName))
+	mg, err := mongo.New(cfg.Mongo.ConnStr.Value(), l, mongo.OptionSet(cfg.Mongo.ConnAttempts, cfg.Mongo.ConnTimeout, cfg.Mongo.DbName, cfg.Mongo.CollectionName))
 	if err != nil {
 		l.Fatal("нет соединения с базой данных", err)
 	}
@@ -97,7 +102,7 @@ func Test_api_addPost_getSpecificPost(t *testing.T) {
 }
 
 func Test_api_getListPost(t *testing.T) {
-	l := logger.NewLogger()
+	l := logger.Discard()
 
 	// Получаем текущий рабочий каталог
 	cwd, err := os.Getwd()
@@ -113,7 +118,7 @@ func Test_api_getListPost(t *testing.T) {
 	if err != nil {
 		l.Fatal("ошибка при разборе конфигурационного файла", err)
 	}
-	mg, err := mongo.New(cfg.Mongo.ConnStr, l, mongo.OptionSet(cfg.Mongo.ConnAttempts, cfg.Mongo.ConnTimeout, cfg.Mongo.DbName, cfg.Mongo.CollectionName))
+	mg, err := mongo.New(cfg.Mongo.ConnStr.Value(), l, mongo.OptionSet(cfg.Mongo.ConnAttempts, cfg.Mongo.ConnTimeout, cfg.Mongo.DbName, cfg.Mongo.CollectionName))
 	if err != nil {
 		l.Fatal("нет соединения с базой данных", err)
 	}
@@ -141,3 +146,189 @@ func Test_api_getListPost(t *testing.T) {
 		t.Log("OK:", http.StatusOK, rr.Body.String())
 	}
 }
+
+func Test_jsonArray(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []listItem
+		want  []listItem
+	}{
+		{name: "пустой список", want: []listItem{}},
+		{name: "несколько объявлений", items: []listItem{{Name: "стол", Price: 100}, {Name: "стул", Price: 50}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			list := newJSONArray(rr)
+			for _, item := range tt.items {
+				if err := list.Write(item); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if list.Started() != (len(tt.items) > 0) {
+				t.Errorf("Started() = %v", list.Started())
+			}
+			if err := list.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type %q", ct)
+			}
+			var got []listItem
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("некорректный JSON %q: %v", rr.Body.String(), err)
+			}
+			want := tt.want
+			if want == nil {
+				want = tt.items
+			}
+			if got == nil || len(got) != len(want) {
+				t.Fatalf("получено %v, ожидалось %v", got, want)
+			}
+			for i := range want {
+				if got[i].Name != want[i].Name || got[i].Price != want[i].Price {
+					t.Errorf("элемент %d = %v, ожидался %v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_api_validateAd(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.Api.MaxLifetime = 30 * 24 * time.Hour
+	a := &api{Cfg: cfg}
+
+	at := func(d time.Duration) *time.Time {
+		v := time.Now().Add(d)
+		return &v
+	}
+	tests := []struct {
+		name    string
+		ad      models.Ads
+		wantErr bool
+	}{
+		{name: "без срока", ad: models.Ads{Name: "стол", Price: 1}},
+		{name: "срок в пределах api.max-lifetime", ad: models.Ads{Name: "стол", Price: 1, ExpiresAt: at(24 * time.Hour)}},
+		{name: "срок в прошлом", ad: models.Ads{Name: "стол", Price: 1, ExpiresAt: at(-time.Minute)}, wantErr: true},
+		{name: "срок больше api.max-lifetime", ad: models.Ads{Name: "стол", Price: 1, ExpiresAt: at(31 * 24 * time.Hour)}, wantErr: true},
+		{name: "без названия", ad: models.Ads{Price: 1}, wantErr: true},
+		{name: "допустимый статус", ad: models.Ads{Name: "стол", Price: 1, Status: models.StatusSold}},
+		{name: "неизвестный статус", ad: models.Ads{Name: "стол", Price: 1, Status: "deleted"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := a.validateAd(tt.ad); (err != nil) != tt.wantErr {
+				t.Errorf("validateAd() = %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_api_addPost_owner(t *testing.T) {
+	repo := &memRepo{}
+	a := newBatchAPI(repo)
+
+	rr := httptest.NewRecorder()
+	a.addPost(rr, httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{"name":"стол","price":100,"owner":"чужой"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Получили code: %v Ожидали %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	a.addPostsBatch(rr, httptest.NewRequest(http.MethodPost, "/posts/batch", strings.NewReader(`[{"name":"стул","price":50,"owner":"чужой"}]`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Получили code: %v Ожидали %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	for _, ad := range repo.ads {
+		if ad.Owner != "" {
+			t.Errorf("объявление %q сохранено с владельцем %q из запроса", ad.Name, ad.Owner)
+		}
+	}
+}
+
+// headerCounter Считает вызовы WriteHeader, чтобы проверить, что код ответа отправлен один раз
+type headerCounter struct {
+	*httptest.ResponseRecorder
+	calls int
+}
+
+func (h *headerCounter) WriteHeader(status int) {
+	h.calls++
+	h.ResponseRecorder.WriteHeader(status)
+}
+
+func (h *headerCounter) Write(b []byte) (int, error) {
+	if h.calls == 0 {
+		h.WriteHeader(http.StatusOK)
+	}
+	return h.ResponseRecorder.Write(b)
+}
+
+func Test_api_getListPost_streamError(t *testing.T) {
+	c, err := compress.New(compress.MinSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &memRepo{
+		ads:       []models.Ads{{Name: "стол", Price: 100}, {Name: "стул", Price: 50}},
+		streamErr: errors.New("курсор закрыт"),
+	}
+	a := newBatchAPI(repo)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		accept  string
+	}{
+		{name: "без middleware", handler: http.HandlerFunc(a.getListPost)},
+		{name: "со сжатием", handler: c.Middleware(recovery.New(a.l).Middleware(http.HandlerFunc(a.getListPost))), accept: "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/posts/list", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			rr := &headerCounter{ResponseRecorder: httptest.NewRecorder()}
+
+			// Начало списка уже отправлено, поэтому ответ прерывается, а не заменяется ошибкой 500
+			func() {
+				defer func() {
+					if v := recover(); v != http.ErrAbortHandler {
+						t.Errorf("ожидалась паника http.ErrAbortHandler, получено %v", v)
+					}
+				}()
+				tt.handler.ServeHTTP(rr, req)
+			}()
+
+			if rr.Code != http.StatusOK || rr.calls != 1 {
+				t.Errorf("код ответа %d отправлен %d раз, ожидался один код 200", rr.Code, rr.calls)
+			}
+			if got := rr.Header().Get("Content-Encoding"); got != tt.accept {
+				t.Errorf("Content-Encoding %q, ожидался %q", got, tt.accept)
+			}
+		})
+	}
+
+	t.Run("ошибка до начала списка", func(t *testing.T) {
+		a := newBatchAPI(&memRepo{streamErr: errors.New("курсор закрыт")})
+		rr := httptest.NewRecorder()
+		a.getListPost(rr, httptest.NewRequest(http.MethodGet, "/posts/list", nil))
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("Получили code: %v Ожидали %v", rr.Code, http.StatusInternalServerError)
+		}
+	})
+}
+
+func Test_api_getListPost_empty(t *testing.T) {
+	a := newBatchAPI(&memRepo{})
+	rr := httptest.NewRecorder()
+	a.getListPost(rr, httptest.NewRequest(http.MethodGet, "/posts/list", nil))
+
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("пустая страница: код %d, тело %q, ожидался пустой массив []", rr.Code, rr.Body.String())
+	}
+}
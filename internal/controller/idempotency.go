@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+const (
+	// idempotencyHeader Заголовок, в котором клиент передаёт ключ идемпотентности
+	idempotencyHeader = "Idempotency-Key"
+	// idempotencyReplayedHeader Заголовок, которым помечается повторно отданный ответ
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLen Максимальная длина ключа идемпотентности
+	maxIdempotencyKeyLen = 255
+)
+
+// idempotent Оборачивает обработчик: первый ответ на запрос с заголовком Idempotency-Key сохраняется,
+// повтор с тем же ключом и тем же телом получает сохранённый ответ,
+// повтор с тем же ключом и другим телом получает 422.
+func (a *api) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if key == "" || a.idem == nil {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			a.log(r.Context()).Debug("Слишком длинный ключ идемпотентности")
+			httpError(w, r, "Слишком длинный ключ идемпотентности", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if tooLarge(err) {
+			a.log(r.Context()).Debug("Слишком большое тело запроса")
+			httpError(w, r, "Слишком большое тело запроса", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			a.log(r.Context()).Error("не удалось прочитать тело запроса", err)
+			httpError(w, r, "не удалось прочитать тело запроса", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := requestHash(r, body)
+		record, reserved, err := a.idem.ReserveIdempotencyKey(r.Context(), key, hash)
+		if err != nil {
+			a.log(r.Context()).Error("Ошибка при проверке ключа идемпотентности", err)
+			httpError(w, r, "Ошибка при проверке ключа идемпотентности", http.StatusInternalServerError)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case record.RequestHash != hash:
+				a.log(r.Context()).Debug("Ключ идемпотентности %s уже использован с другим запросом", key)
+				httpError(w, r, "Ключ идемпотентности уже использован с другим запросом", http.StatusUnprocessableEntity)
+			case !record.Completed():
+				a.log(r.Context()).Debug("Запрос с ключом идемпотентности %s ещё выполняется", key)
+				httpError(w, r, "Запрос с этим ключом идемпотентности ещё выполняется", http.StatusConflict)
+			default:
+				if record.ContentType != "" {
+					w.Header().Set("Content-Type", record.ContentType)
+				}
+				w.Header().Set(idempotencyReplayedHeader, "true")
+				w.WriteHeader(record.Status)
+				if _, err = w.Write(record.Body); err != nil {
+					a.log(r.Context()).Error("Ошибка записи сохранённого ответа", err)
+				}
+			}
+			return
+		}
+
+		// Результат сохраняется и после отключения клиента
+		ctx := context.WithoutCancel(r.Context())
+
+		// Паника в обработчике превращается в ответ 500 выше по цепочке,
+		// ключ освобождается, иначе повтор запроса получал бы 409 до истечения срока ключа
+		defer func() {
+			if v := recover(); v != nil {
+				a.releaseIdempotencyKey(ctx, key)
+				panic(v)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next(rec, r)
+
+		// Ответы с ошибкой сервера не сохраняются, чтобы клиент мог повторить запрос
+		if rec.status() >= http.StatusInternalServerError {
+			a.releaseIdempotencyKey(ctx, key)
+			return
+		}
+		err = a.idem.SaveIdempotencyResponse(ctx, key, rec.status(), w.Header().Get("Content-Type"), rec.body.Bytes())
+		if err != nil {
+			a.log(r.Context()).Error("Ошибка при сохранении ответа для ключа идемпотентности", err)
+		}
+	}
+}
+
+// releaseIdempotencyKey Освобождает ключ, чтобы клиент мог повторить запрос
+func (a *api) releaseIdempotencyKey(ctx context.Context, key string) {
+	if err := a.idem.ReleaseIdempotencyKey(ctx, key); err != nil {
+		a.log(ctx).Error("Ошибка при освобождении ключа идемпотентности", err)
+	}
+}
+
+// requestHash Вычисляет отпечаток запроса. JSON тело приводится к каноническому виду,
+// чтобы отличия в пробелах и порядке ключей не считались другим запросом.
+func requestHash(r *http.Request, body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			body = canonical
+		}
+	}
+
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder Передаёт ответ клиенту и одновременно сохраняет его копию
+type responseRecorder struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	if rr.code == 0 {
+		rr.code = code
+	}
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.code == 0 {
+		rr.code = http.StatusOK
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+func (rr *responseRecorder) status() int {
+	if rr.code == 0 {
+		return http.StatusOK
+	}
+	return rr.code
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/models"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// memIdempotency Хранилище ключей идемпотентности в памяти для тестов
+type memIdempotency struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+func (m *memIdempotency) ReserveIdempotencyKey(_ context.Context, key, requestHash string) (models.IdempotencyRecord, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rec, ok := m.records[key]; ok {
+		return rec, false, nil
+	}
+	rec := models.IdempotencyRecord{Key: key, RequestHash: requestHash}
+	m.records[key] = rec
+	return rec, true, nil
+}
+
+func (m *memIdempotency) SaveIdempotencyResponse(_ context.Context, key string, status int, contentType string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec := m.records[key]
+	rec.Status, rec.ContentType, rec.Body = status, contentType, body
+	m.records[key] = rec
+	return nil
+}
+
+func (m *memIdempotency) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, key)
+	return nil
+}
+
+func Test_api_idempotent(t *testing.T) {
+	a := &api{
+		l:    logger.Discard(),
+		idem: &memIdempotency{records: map[string]models.IdempotencyRecord{}},
+	}
+
+	calls := 0
+	h := a.idempotent(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, `{"id":"%d"}`, calls)
+	})
+
+	do := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(body))
+		req.Header.Set(idempotencyHeader, key)
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		return rr
+	}
+
+	first := do("key-1", `{"name": "реклама", "price": 10}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("Получили code: %v Ожидали %v", first.Code, http.StatusOK)
+	}
+
+	// Тот же запрос с другим форматированием JSON должен получить сохранённый ответ
+	replay := do("key-1", `{"price":10,"name":"реклама"}`)
+	if replay.Code != http.StatusOK || replay.Body.String() != first.Body.String() {
+		t.Errorf("Ожидали повтор ответа %q, получили %v %q", first.Body.String(), replay.Code, replay.Body.String())
+	}
+	if replay.Header().Get(idempotencyReplayedHeader) != "true" {
+		t.Errorf("Ожидали заголовок %s", idempotencyReplayedHeader)
+	}
+	if calls != 1 {
+		t.Errorf("Обработчик вызван %d раз, ожидали 1", calls)
+	}
+
+	conflict := do("key-1", `{"name": "другая реклама", "price": 10}`)
+	if conflict.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Получили code: %v Ожидали %v", conflict.Code, http.StatusUnprocessableEntity)
+	}
+
+	if other := do("key-2", `{"name": "реклама", "price": 10}`); other.Code != http.StatusOK || calls != 2 {
+		t.Errorf("Новый ключ должен выполнить запрос, получили %v, вызовов %d", other.Code, calls)
+	}
+}
+
+func Test_api_idempotent_panic(t *testing.T) {
+	a := &api{
+		l:    logger.Discard(),
+		idem: &memIdempotency{records: map[string]models.IdempotencyRecord{}},
+	}
+
+	calls := 0
+	h := a.idempotent(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("сбой")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	do := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{"name": "реклама", "price": 10}`))
+		req.Header.Set(idempotencyHeader, "key-1")
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		return rr
+	}
+
+	func() {
+		defer func() {
+			if v := recover(); v != "сбой" {
+				t.Errorf("паника должна передаваться дальше, получено %v", v)
+			}
+		}()
+		do()
+	}()
+
+	// Повтор с тем же ключом выполняется, а не получает 409
+	if retry := do(); retry.Code != http.StatusOK || calls != 2 {
+		t.Errorf("Получили code: %v, вызовов %d, ожидали %v и 2 вызова", retry.Code, calls, http.StatusOK)
+	}
+}
+
+func Test_api_idempotent_bodyLimit(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.Api.MaxBodyBytes = 16
+	a := &api{
+		Cfg:  cfg,
+		l:    logger.Discard(),
+		idem: &memIdempotency{records: map[string]models.IdempotencyRecord{}},
+	}
+	h := a.limitBody(a.maxBodyBytes, a.idempotent(func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("обработчик не должен вызываться")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{"name": "слишком длинное объявление"}`))
+	req.Header.Set(idempotencyHeader, "key-1")
+	rr := httptest.NewRecorder()
+	h(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Получили code: %v Ожидали %v", rr.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"github.com/gorilla/mux"
 	"zatrasz75/Ads_service/configs"
 	_ "zatrasz75/Ads_service/docs"
+	"zatrasz75/Ads_service/internal/jobs"
 	"zatrasz75/Ads_service/internal/repository"
+	"zatrasz75/Ads_service/pkg/health"
 	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/requestid"
+	"zatrasz75/Ads_service/pkg/tracing"
 )
 
 // @title Swagger API
@@ -19,10 +23,32 @@ import (
 
 // @BasePath /
 
-// NewRouter -.
-func NewRouter(cfg *configs.Config, l logger.LoggersInterface, repo *repository.Store) *mux.Router {
-	r := mux.NewRouter()
-	newEndpoint(r, cfg, l, repo)
+// NewRouter Публичный API объявлений /posts*.
+// Если reloader не nil, обработчики используют параметры из перезагруженной конфигурации.
+// mw подключаются в указанном порядке после трассировки и идентификатора запроса.
+func NewRouter(cfg *configs.Config, l logger.LoggersInterface, repo *repository.Store, reloader *configs.Reloader, mw ...mux.MiddlewareFunc) *mux.Router {
+	r := newMuxRouter(l, mw)
+	newEndpoint(r, newAPI(cfg, l, repo, reloader, nil, nil))
+	return r
+}
+
+// NewAdminRouter Служебные маршруты для отдельного адреса admin.addr. Все маршруты, кроме /healthz и /readyz,
+// требуют токен admin.token. checker выполняет проверки готовности для /readyz и /health, nil означает отсутствие проверок.
+// В registry выполняются задачи загрузки, при остановке сервиса его нужно закрыть, чтобы прервать незавершённые задачи.
+func NewAdminRouter(cfg *configs.Config, l logger.LoggersInterface, repo *repository.Store, reloader *configs.Reloader, checker *health.Checker, registry *jobs.Registry, mw ...mux.MiddlewareFunc) *mux.Router {
+	if checker == nil {
+		checker = health.New()
+	}
 
+	en := newAPI(cfg, l, repo, reloader, checker, registry)
+	r := newMuxRouter(l, append(mw, en.adminAuth))
+	newAdminEndpoint(r, en)
+	return r
+}
+
+func newMuxRouter(l logger.LoggersInterface, mw []mux.MiddlewareFunc) *mux.Router {
+	r := mux.NewRouter()
+	r.Use(tracing.Middleware, requestid.Middleware(l))
+	r.Use(mw...)
 	return r
 }
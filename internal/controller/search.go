@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"zatrasz75/Ads_service/pkg/search"
+)
+
+const (
+	// maxSearchQueryLen Максимальная длина поискового запроса
+	maxSearchQueryLen = 256
+	// snippetLen Длина фрагмента описания с подсветкой
+	snippetLen = 160
+)
+
+// searchItem Найденное объявление с подсвеченными фрагментами
+type searchItem struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Price      float64 `json:"price"`
+	Score      float64 `json:"score"`
+	Highlights struct {
+		Name        string `json:"name,omitempty"`
+		Description string `json:"description,omitempty"`
+	} `json:"highlights"`
+}
+
+// @Summary Полнотекстовый поиск объявлений
+// @Description Метод для поиска объявлений по словам из названия и описания.
+// @Description Результаты упорядочены по релевантности, совпадения в названии весят больше.
+// @Description Слова запроса приводятся к основе с учётом языка (русский или английский).
+// @Description Найденные слова в названии и фрагменте описания обрамляются тегами <em></em>.
+// @Accept json
+// @Produce json
+// @Param q query string true "Поисковый запрос"
+// @Param page query int false "Номер страницы для пагинации (по умолчанию 1)"
+// @Success 200 {array} searchItem
+// @Failure 400 {string} string "Не удалось получить параметр q"
+// @Failure 400 {string} string "Слишком длинный поисковый запрос"
+// @Failure 500 {string} string "Ошибка при поиске объявлений"
+// @Router /posts/search [get]
+// @OperationId searchPosts
+func (a *api) searchPosts(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	q := strings.TrimSpace(queryParams.Get("q"))
+	if q == "" {
+		a.log(r.Context()).Debug("Не удалось получить параметр q")
+		httpError(w, r, "Не удалось получить параметр q", http.StatusBadRequest)
+		return
+	}
+	if len([]rune(q)) > maxSearchQueryLen {
+		a.log(r.Context()).Debug("Слишком длинный поисковый запрос")
+		httpError(w, r, "Слишком длинный поисковый запрос", http.StatusBadRequest)
+		return
+	}
+	page, err := strconv.Atoi(queryParams.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	results, err := a.repo.SearchPosts(r.Context(), q, page)
+	if err != nil {
+		a.log(r.Context()).Error("Ошибка при поиске объявлений", err)
+		httpError(w, r, "Ошибка при поиске объявлений", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]searchItem, 0, len(results))
+	for _, res := range results {
+		item := searchItem{
+			ID:    res.ID,
+			Name:  res.Name,
+			Price: res.Price,
+			Score: res.Score,
+		}
+		item.Highlights.Name = search.Highlight(res.Name, q, 0)
+		item.Highlights.Description = search.Highlight(res.Description, q, snippetLen)
+		response = append(response, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	err = writeJSON(r.Context(), w, response)
+	if err != nil {
+		a.log(r.Context()).Error("Ошибка при сериализации ответа JSON", err)
+		httpError(w, r, "Ошибка при сериализации ответа JSON", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,209 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Статусы фоновой задачи
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// maxErrors Максимальное количество ошибок, сохраняемых в задаче
+const maxErrors = 1000
+
+// LineError Ошибка обработки строки входных данных
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Job Состояние фоновой задачи
+type Job struct {
+	ID         string      `json:"id"`
+	Kind       string      `json:"kind"`
+	Status     string      `json:"status"`
+	Processed  int         `json:"processed"`
+	Succeeded  int         `json:"succeeded"`
+	Failed     int         `json:"failed"`
+	Errors     []LineError `json:"errors"`
+	Truncated  bool        `json:"errorsTruncated,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	StartedAt  time.Time   `json:"startedAt"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+}
+
+// Progress Позволяет задаче сообщать о ходе выполнения
+type Progress struct {
+	mu  *sync.RWMutex
+	job *Job
+}
+
+// Succeed Учитывает успешно обработанные строки
+func (p *Progress) Succeed(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Processed += n
+	p.job.Succeeded += n
+}
+
+// Fail Учитывает строку, обработанную с ошибкой
+func (p *Progress) Fail(line int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Processed++
+	p.job.Failed++
+	if len(p.job.Errors) < maxErrors {
+		p.job.Errors = append(p.job.Errors, LineError{Line: line, Error: err.Error()})
+	} else {
+		p.job.Truncated = true
+	}
+}
+
+// Func Функция, выполняемая в фоне
+type Func func(ctx context.Context, p *Progress) error
+
+// Registry Реестр фоновых задач, хранящий их состояние в памяти процесса
+type Registry struct {
+	mu        sync.RWMutex
+	jobs      map[string]*Job
+	retention time.Duration
+
+	// ctx Контекст выполнения задач, отменяется в Close
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRegistry Создаёт реестр, завершённые задачи хранятся retention
+func NewRegistry(retention time.Duration) *Registry {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Registry{
+		jobs:      make(map[string]*Job),
+		retention: retention,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Close Отменяет выполняемые задачи и ждёт их завершения не дольше, чем позволяет ctx.
+// Задачи, запущенные после Close, сразу завершаются с ошибкой отмены.
+func (r *Registry) Close(ctx context.Context) error {
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("фоновые задачи не завершились: %w", ctx.Err())
+	}
+}
+
+// Start Запускает задачу в отдельной горутине и возвращает её состояние на момент запуска
+func (r *Registry) Start(kind string, fn Func) Job {
+	job := newJob(kind)
+
+	r.mu.Lock()
+	r.cleanup()
+	r.jobs[job.ID] = job
+	snapshot := r.snapshot(job)
+	r.mu.Unlock()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		err := run(r.ctx, fn, &Progress{mu: &r.mu, job: job})
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		job.finish(err)
+	}()
+
+	return snapshot
+}
+
+// Run Выполняет задачу в текущей горутине и возвращает её итоговое состояние.
+// Используется там, где фоновое выполнение не нужно, например в командах исполняемого файла.
+func Run(ctx context.Context, kind string, fn Func) Job {
+	job := newJob(kind)
+	err := run(ctx, fn, &Progress{mu: &sync.RWMutex{}, job: job})
+	job.finish(err)
+	return *job
+}
+
+func newJob(kind string) *Job {
+	return &Job{
+		ID:        newID(),
+		Kind:      kind,
+		Status:    StatusRunning,
+		Errors:    []LineError{},
+		StartedAt: time.Now().UTC(),
+	}
+}
+
+// finish Записывает результат выполнения задачи
+func (job *Job) finish(err error) {
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = StatusCompleted
+	}
+}
+
+// run Выполняет задачу, превращая панику в ошибку
+func run(ctx context.Context, fn Func, p *Progress) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("паника в фоновой задаче: %v", rec)
+		}
+	}()
+	return fn(ctx, p)
+}
+
+// Get Возвращает копию состояния задачи
+func (r *Registry) Get(id string) (Job, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return r.snapshot(job), true
+}
+
+func (r *Registry) snapshot(job *Job) Job {
+	c := *job
+	c.Errors = append([]LineError(nil), job.Errors...)
+	return c
+}
+
+// cleanup Удаляет завершённые задачи старше retention. Вызывается под блокировкой.
+func (r *Registry) cleanup() {
+	deadline := time.Now().Add(-r.retention)
+	for id, job := range r.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(deadline) {
+			delete(r.jobs, id)
+		}
+	}
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
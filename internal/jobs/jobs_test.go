@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Close(t *testing.T) {
+	r := NewRegistry(time.Hour)
+
+	started := make(chan struct{})
+	job := r.Start("import", func(ctx context.Context, p *Progress) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := r.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	got, _ := r.Get(job.ID)
+	if got.Status != StatusFailed || got.Error != context.Canceled.Error() {
+		t.Errorf("задача после Close: статус %s, ошибка %q", got.Status, got.Error)
+	}
+}
+
+func TestRegistry_CloseTimeout(t *testing.T) {
+	r := NewRegistry(time.Hour)
+
+	release := make(chan struct{})
+	defer close(release)
+	r.Start("import", func(context.Context, *Progress) error {
+		// Задача не проверяет отмену
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ожидалась ошибка истечения времени, получено %v", err)
+	}
+}
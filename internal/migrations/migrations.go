@@ -0,0 +1,328 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"sort"
+	"time"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// lockID Идентификатор документа блокировки в коллекции миграций
+const lockID = "lock"
+
+var (
+	// ErrLocked Миграции уже выполняются другим процессом
+	ErrLocked = errors.New("миграции уже выполняются другим процессом")
+	// ErrLockLost Блокировка потеряна во время выполнения миграций: её не удалось продлить или её перехватил другой процесс
+	ErrLockLost = errors.New("блокировка миграций потеряна")
+)
+
+// Func Функция миграции
+type Func func(ctx context.Context, db *mongo.Database, cfg *configs.Config) error
+
+// Migration Миграция схемы коллекции объявлений
+type Migration struct {
+	Version     int
+	Description string
+	Up          Func
+	Down        Func
+}
+
+// Status Состояние миграции
+type Status struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"appliedAt,omitempty"`
+}
+
+// record Запись о применённой миграции
+type record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// Migrator Применяет и откатывает миграции, учитывая применённые версии в коллекции миграций
+type Migrator struct {
+	db         *mongo.Database
+	cfg        *configs.Config
+	l          logger.LoggersInterface
+	migrations []Migration
+	owner      string
+}
+
+// New Создаёт Migrator со списком миграций приложения
+func New(db *mongo.Database, cfg *configs.Config, l logger.LoggersInterface) *Migrator {
+	return NewWithMigrations(db, cfg, l, registry)
+}
+
+// NewWithMigrations Создаёт Migrator с указанным списком миграций
+func NewWithMigrations(db *mongo.Database, cfg *configs.Config, l logger.LoggersInterface, migrations []Migration) *Migrator {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	host, _ := os.Hostname()
+	return &Migrator{
+		db:         db,
+		cfg:        cfg,
+		l:          l,
+		migrations: sorted,
+		owner:      fmt.Sprintf("%s:%d:%d", host, os.Getpid(), time.Now().UnixNano()),
+	}
+}
+
+// Status Возвращает состояние всех миграций
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		st := Status{Version: mg.Version, Description: mg.Description}
+		if rec, ok := applied[mg.Version]; ok {
+			appliedAt := rec.AppliedAt
+			st.Applied = true
+			st.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// Pending Возвращает количество неприменённых миграций
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, mg := range m.migrations {
+		if _, ok := applied[mg.Version]; !ok {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Up Применяет все неприменённые миграции по возрастанию версии
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.unlock()
+	ctx, release := m.hold(ctx)
+	defer release()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, mg := range m.migrations {
+		if _, ok := applied[mg.Version]; ok {
+			continue
+		}
+
+		m.l.Info("Применение миграции %d: %s", mg.Version, mg.Description)
+		if err = mg.Up(ctx, m.db, m.cfg); err != nil {
+			return count, fmt.Errorf("миграция %d: %w", mg.Version, cause(ctx, err))
+		}
+		rec := record{Version: mg.Version, Description: mg.Description, AppliedAt: time.Now().UTC()}
+		if _, err = m.collection().InsertOne(ctx, rec); err != nil {
+			return count, fmt.Errorf("не удалось сохранить версию миграции %d: %w", mg.Version, cause(ctx, err))
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Down Откатывает steps последних применённых миграций
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.unlock()
+	ctx, release := m.hold(ctx)
+	defer release()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+		mg := m.migrations[i]
+		if _, ok := applied[mg.Version]; !ok {
+			continue
+		}
+		if mg.Down == nil {
+			return count, fmt.Errorf("миграция %d не поддерживает откат", mg.Version)
+		}
+
+		m.l.Info("Откат миграции %d: %s", mg.Version, mg.Description)
+		if err = mg.Down(ctx, m.db, m.cfg); err != nil {
+			return count, fmt.Errorf("откат миграции %d: %w", mg.Version, cause(ctx, err))
+		}
+		if _, err = m.collection().DeleteOne(ctx, bson.M{"_id": mg.Version}); err != nil {
+			return count, fmt.Errorf("не удалось удалить версию миграции %d: %w", mg.Version, cause(ctx, err))
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// applied Возвращает применённые миграции по версиям
+func (m *Migrator) applied(ctx context.Context) (map[int]record, error) {
+	// Документ блокировки хранится в той же коллекции, но имеет строковый _id
+	cursor, err := m.collection().Find(ctx, bson.M{"_id": bson.M{"$type": "number"}})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении применённых миграций: %w", err)
+	}
+	defer cursor.Close(context.Background())
+
+	var records []record
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении применённых миграций: %w", err)
+	}
+
+	applied := make(map[int]record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// lock Захватывает блокировку, чтобы миграции не выполнялись одновременно несколькими процессами.
+// Пока миграции выполняются, блокировка продлевается (hold). Блокировка, не продлённая за LockTTL
+// (например, после падения процесса), считается устаревшей.
+func (m *Migrator) lock(ctx context.Context) error {
+	now := time.Now().UTC()
+	doc := bson.M{
+		"_id":       lockID,
+		"owner":     m.owner,
+		"lockedAt":  now,
+		"expiresAt": now.Add(m.cfg.Migrations.LockTTL),
+	}
+
+	_, err := m.collection().InsertOne(ctx, doc)
+	if err == nil {
+		return nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("не удалось захватить блокировку миграций: %w", err)
+	}
+
+	// Перехват устаревшей блокировки одной атомарной операцией
+	res := m.collection().FindOneAndReplace(ctx,
+		bson.M{"_id": lockID, "expiresAt": bson.M{"$lt": now}},
+		doc,
+		options.FindOneAndReplace().SetReturnDocument(options.After),
+	)
+	if errors.Is(res.Err(), mongo.ErrNoDocuments) {
+		return ErrLocked
+	}
+	if res.Err() != nil {
+		return fmt.Errorf("не удалось захватить блокировку миграций: %w", res.Err())
+	}
+
+	m.l.Warn("Перехвачена устаревшая блокировка миграций")
+	return nil
+}
+
+// unlock Снимает блокировку, если она принадлежит этому процессу
+func (m *Migrator) unlock() {
+	_, err := m.collection().DeleteOne(context.Background(), bson.M{"_id": lockID, "owner": m.owner})
+	if err != nil {
+		m.l.Error("не удалось снять блокировку миграций", err)
+	}
+}
+
+// hold Продлевает блокировку каждые LockTTL/3, пока выполняются миграции, release останавливает продление.
+// Если блокировку перехватил другой процесс или её не удаётся продлить дольше LockTTL/2,
+// возвращённый контекст отменяется с причиной ErrLockLost, и миграции прерываются до истечения блокировки.
+func (m *Migrator) hold(ctx context.Context) (_ context.Context, release func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ttl := m.cfg.Migrations.LockTTL
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+
+		renewed := time.Now()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			err := m.renew(ctx)
+			switch {
+			case err == nil:
+				renewed = time.Now()
+			case errors.Is(err, ErrLockLost):
+				m.l.Error("блокировку миграций перехватил другой процесс", err)
+				cancel(err)
+				return
+			case time.Since(renewed) > ttl/2:
+				m.l.Error("не удалось продлить блокировку миграций", err)
+				cancel(fmt.Errorf("%w: %v", ErrLockLost, err))
+				return
+			default:
+				m.l.Warn("не удалось продлить блокировку миграций, повтор через %s: %v", ttl/3, err)
+			}
+		}
+	}()
+
+	return ctx, func() {
+		close(stop)
+		<-done
+		cancel(nil)
+	}
+}
+
+// renew Продлевает блокировку этого процесса на LockTTL
+func (m *Migrator) renew(ctx context.Context) error {
+	res, err := m.collection().UpdateOne(ctx,
+		bson.M{"_id": lockID, "owner": m.owner},
+		bson.M{"$set": bson.M{"expiresAt": time.Now().UTC().Add(m.cfg.Migrations.LockTTL)}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// cause Заменяет ошибку отменённого контекста причиной отмены, например ErrLockLost
+func cause(ctx context.Context, err error) error {
+	if c := context.Cause(ctx); c != nil && !errors.Is(c, context.Canceled) && !errors.Is(c, context.DeadlineExceeded) {
+		return c
+	}
+	return err
+}
+
+func (m *Migrator) collection() *mongo.Collection {
+	return m.db.Collection(m.cfg.Migrations.CollectionName)
+}
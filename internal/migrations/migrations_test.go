@@ -0,0 +1,213 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/models"
+	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/mongo"
+)
+
+// testDB Подключается к MongoDB из configs.yml и задаёт отдельные коллекции объявлений и миграций,
+// которые удаляются после теста. Если база недоступна, тест пропускается.
+func testDB(t *testing.T) (*mongodriver.Database, *configs.Config) {
+	t.Helper()
+	l := logger.Discard()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := configs.NewConfig(filepath.Join(cwd, "..", "..", "configs", "configs.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	suffix := fmt.Sprintf("_test_%d", time.Now().UnixNano())
+	cfg.Mongo.CollectionName += suffix
+	cfg.Migrations.CollectionName += suffix
+
+	mg, err := mongo.New(cfg.Mongo.ConnStr.Value(), l,
+		mongo.OptionSet(1, time.Second, cfg.Mongo.DbName, cfg.Mongo.CollectionName),
+		mongo.Timeouts(2*time.Second, 0),
+		mongo.ConnDeadline(3*time.Second),
+	)
+	if err != nil {
+		t.Skipf("MongoDB недоступна: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_ = mg.Collection(cfg.Mongo.CollectionName).Drop(ctx)
+		_ = mg.Collection(cfg.Migrations.CollectionName).Drop(ctx)
+		_ = mg.Close(ctx)
+	})
+
+	return mg.Database(), cfg
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	db, cfg := testDB(t)
+	ctx := context.Background()
+	collection := ads(db, cfg)
+
+	// Старое объявление без новых полей и объявление, где поля заданы пользователем
+	legacyID, userID := primitive.NewObjectID(), primitive.NewObjectID()
+	_, err := collection.InsertMany(ctx, []interface{}{
+		bson.M{"_id": legacyID, "name": "стол", "description": "дубовый"},
+		bson.M{"_id": userID, "name": "chair", "currency": "USD", "status": models.StatusSold, "lang": "english"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	find := func(id primitive.ObjectID) bson.M {
+		t.Helper()
+		var doc bson.M
+		if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&doc); err != nil {
+			t.Fatal(err)
+		}
+		delete(doc, "_id")
+		return doc
+	}
+	user := find(userID)
+
+	m := New(db, cfg, logger.Discard())
+	applied, err := m.Up(ctx)
+	if err != nil || applied != len(registry) {
+		t.Fatalf("Up() = %d, %v, ожидалось %d", applied, err, len(registry))
+	}
+	if pending, err := m.Pending(ctx); err != nil || pending != 0 {
+		t.Errorf("Pending() = %d, %v после Up", pending, err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range statuses {
+		if !st.Applied || st.AppliedAt == nil {
+			t.Errorf("миграция %d не отмечена применённой: %+v", st.Version, st)
+		}
+	}
+	legacy := find(legacyID)
+	if legacy["currency"] != models.DefaultCurrency || legacy["status"] != models.StatusActive || legacy["lang"] == nil {
+		t.Errorf("поля не заполнены: %v", legacy)
+	}
+	if got := find(userID); fmt.Sprint(got) != fmt.Sprint(user) {
+		t.Errorf("изменено объявление с заданными полями: %v", got)
+	}
+
+	// Повторный Up ничего не применяет
+	if applied, err = m.Up(ctx); err != nil || applied != 0 {
+		t.Errorf("повторный Up() = %d, %v", applied, err)
+	}
+
+	// Статус, изменённый после миграции, принадлежит пользователю и не удаляется откатом
+	if _, err = collection.UpdateOne(ctx, bson.M{"_id": legacyID}, bson.M{"$set": bson.M{"status": models.StatusArchived}}); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := m.Down(ctx, len(registry))
+	if err != nil || reverted != len(registry) {
+		t.Fatalf("Down() = %d, %v, ожидалось %d", reverted, err, len(registry))
+	}
+	if pending, err := m.Pending(ctx); err != nil || pending != len(registry) {
+		t.Errorf("Pending() = %d, %v после Down", pending, err)
+	}
+	legacy = find(legacyID)
+	want := bson.M{"name": "стол", "description": "дубовый", "status": models.StatusArchived}
+	if fmt.Sprint(legacy) != fmt.Sprint(want) {
+		t.Errorf("после отката %v, ожидалось %v", legacy, want)
+	}
+	if got := find(userID); fmt.Sprint(got) != fmt.Sprint(user) {
+		t.Errorf("откат изменил данные пользователя: %v", got)
+	}
+}
+
+func TestMigrator_lock(t *testing.T) {
+	db, cfg := testDB(t)
+	ctx := context.Background()
+	locks := db.Collection(cfg.Migrations.CollectionName)
+
+	m := NewWithMigrations(db, cfg, logger.Discard(), nil)
+
+	// Действующая блокировка другого процесса
+	_, err := locks.InsertOne(ctx, bson.M{"_id": lockID, "owner": "другой", "expiresAt": time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Up(ctx); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Up() = %v, ожидалась ErrLocked", err)
+	}
+	if _, err = m.Down(ctx, 1); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Down() = %v, ожидалась ErrLocked", err)
+	}
+
+	// Устаревшая блокировка перехватывается и снимается после выполнения
+	_, err = locks.UpdateOne(ctx, bson.M{"_id": lockID}, bson.M{"$set": bson.M{"expiresAt": time.Now().Add(-time.Minute)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Up(ctx); err != nil {
+		t.Fatalf("Up() с устаревшей блокировкой: %v", err)
+	}
+	if n, err := locks.CountDocuments(ctx, bson.M{"_id": lockID}); err != nil || n != 0 {
+		t.Errorf("блокировка не снята: %d, %v", n, err)
+	}
+}
+
+func TestMigrator_lockRenewal(t *testing.T) {
+	db, cfg := testDB(t)
+	cfg.Migrations.LockTTL = 300 * time.Millisecond
+	locks := db.Collection(cfg.Migrations.CollectionName)
+
+	lockExpiry := func(ctx context.Context) time.Time {
+		var doc struct {
+			ExpiresAt time.Time `bson:"expiresAt"`
+		}
+		if err := locks.FindOne(ctx, bson.M{"_id": lockID}).Decode(&doc); err != nil {
+			t.Error(err)
+		}
+		return doc.ExpiresAt
+	}
+
+	t.Run("продление", func(t *testing.T) {
+		long := Migration{Version: 1, Description: "долгая", Up: func(ctx context.Context, _ *mongodriver.Database, _ *configs.Config) error {
+			first := lockExpiry(ctx)
+			time.Sleep(2 * cfg.Migrations.LockTTL)
+			if last := lockExpiry(ctx); !last.After(first) {
+				return fmt.Errorf("блокировка не продлена: %v", last)
+			}
+			return ctx.Err()
+		}}
+		m := NewWithMigrations(db, cfg, logger.Discard(), []Migration{long})
+		if _, err := m.Up(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("перехват", func(t *testing.T) {
+		stolen := Migration{Version: 2, Description: "перехваченная", Up: func(ctx context.Context, _ *mongodriver.Database, _ *configs.Config) error {
+			_, err := locks.UpdateOne(ctx, bson.M{"_id": lockID}, bson.M{"$set": bson.M{"owner": "другой"}})
+			if err != nil {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * cfg.Migrations.LockTTL):
+				return errors.New("миграция не прервана после потери блокировки")
+			}
+		}}
+		m := NewWithMigrations(db, cfg, logger.Discard(), []Migration{stolen})
+		if _, err := m.Up(context.Background()); !errors.Is(err, ErrLockLost) {
+			t.Fatalf("Up() = %v, ожидалась ErrLockLost", err)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/models"
+	"zatrasz75/Ads_service/pkg/search"
+)
+
+// registry Миграции приложения. Версии не меняются после выпуска, новые миграции добавляются в конец.
+var registry = []Migration{
+	{
+		Version:     1,
+		Description: "заполнение валюты объявлений",
+		Up:          backfill("currency", models.DefaultCurrency),
+		Down:        revert("currency", models.DefaultCurrency),
+	},
+	{
+		Version:     2,
+		Description: "заполнение статуса объявлений",
+		Up:          backfill("status", models.StatusActive),
+		Down:        revert("status", models.StatusActive),
+	},
+	{
+		Version:     3,
+		Description: "определение языка объявлений для текстового индекса",
+		Up:          backfillLanguage,
+		Down:        revert("lang", nil),
+	},
+}
+
+// markerField Поле со списком полей, которые заполнила миграция. По нему откат отличает записанные
+// миграцией значения от данных пользователей и не удаляет последние.
+const markerField = "backfilled"
+
+// backfill Записывает значение поля в документы, где поле отсутствует, и отмечает их в markerField
+func backfill(field string, value interface{}) Func {
+	return func(ctx context.Context, db *mongo.Database, cfg *configs.Config) error {
+		_, err := ads(db, cfg).UpdateMany(ctx,
+			bson.M{field: bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{field: value}, "$addToSet": bson.M{markerField: field}},
+		)
+		return err
+	}
+}
+
+// revert Удаляет поле только из документов, которые заполнила миграция. Если value не nil,
+// поле удаляется, только пока в нём осталось записанное миграцией значение.
+// Отметка снимается со всех документов, в том числе с изменённых после миграции.
+func revert(field string, value interface{}) Func {
+	return func(ctx context.Context, db *mongo.Database, cfg *configs.Config) error {
+		collection := ads(db, cfg)
+
+		filter := bson.M{markerField: field}
+		if value != nil {
+			filter[field] = value
+		}
+		if _, err := collection.UpdateMany(ctx, filter, bson.M{"$unset": bson.M{field: ""}}); err != nil {
+			return err
+		}
+
+		_, err := collection.UpdateMany(ctx,
+			bson.M{markerField: field},
+			bson.M{"$pull": bson.M{markerField: field}},
+		)
+		if err != nil {
+			return err
+		}
+		_, err = collection.UpdateMany(ctx,
+			bson.M{markerField: bson.M{"$size": 0}},
+			bson.M{"$unset": bson.M{markerField: ""}},
+		)
+		return err
+	}
+}
+
+// backfillLanguage Определяет язык по названию и описанию для документов без поля lang и отмечает их в markerField
+func backfillLanguage(ctx context.Context, db *mongo.Database, cfg *configs.Config) error {
+	collection := ads(db, cfg)
+
+	cursor, err := collection.Find(ctx, bson.M{"lang": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID          primitive.ObjectID `bson:"_id"`
+			Name        string             `bson:"name"`
+			Description string             `bson:"description"`
+		}
+		if err = cursor.Decode(&doc); err != nil {
+			return err
+		}
+
+		lang := search.DetectLanguage(doc.Name + " " + doc.Description)
+		_, err = collection.UpdateOne(ctx,
+			bson.M{"_id": doc.ID},
+			bson.M{"$set": bson.M{"lang": lang}, "$addToSet": bson.M{markerField: "lang"}},
+		)
+		if err != nil {
+			return fmt.Errorf("объявление %s: %w", doc.ID.Hex(), err)
+		}
+	}
+
+	return cursor.Err()
+}
+
+func ads(db *mongo.Database, cfg *configs.Config) *mongo.Collection {
+	return db.Collection(cfg.Mongo.CollectionName)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+	"zatrasz75/Ads_service/models"
+)
+
+// ReserveIdempotencyKey Резервирует ключ идемпотентности.
+// Если ключ уже занят, возвращает существующую запись и false.
+func (s *Store) ReserveIdempotencyKey(ctx context.Context, key, requestHash string) (_ models.IdempotencyRecord, _ bool, err error) {
+	ctx, op := s.begin(ctx, "ReserveIdempotencyKey")
+	defer op.end(&err)
+
+	now := time.Now().UTC()
+	record := models.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.cfg.Idempotency.TTL),
+	}
+
+	// Две попытки: TTL монитор MongoDB удаляет документы с задержкой,
+	// поэтому просроченная запись удаляется вручную и ключ резервируется повторно
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := s.idempotencyCollection().InsertOne(ctx, record)
+		if err == nil {
+			return record, true, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			s.log(ctx).Error("Ошибка при резервировании ключа идемпотентности", err)
+			return models.IdempotencyRecord{}, false, fmt.Errorf("ошибка при резервировании ключа идемпотентности: %w", err)
+		}
+
+		var existing models.IdempotencyRecord
+		err = s.idempotencyCollection().FindOne(ctx, bson.M{"_id": key}).Decode(&existing)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Запись успели удалить, пробуем ещё раз
+			continue
+		}
+		if err != nil {
+			s.log(ctx).Error("Ошибка при поиске ключа идемпотентности", err)
+			return models.IdempotencyRecord{}, false, fmt.Errorf("ошибка при поиске ключа идемпотентности: %w", err)
+		}
+
+		if existing.ExpiresAt.After(now) {
+			return existing, false, nil
+		}
+		if err = s.ReleaseIdempotencyKey(ctx, key); err != nil {
+			return models.IdempotencyRecord{}, false, err
+		}
+	}
+
+	return models.IdempotencyRecord{}, false, fmt.Errorf("не удалось зарезервировать ключ идемпотентности %q", key)
+}
+
+// SaveIdempotencyResponse Сохраняет ответ, полученный для ключа идемпотентности
+func (s *Store) SaveIdempotencyResponse(ctx context.Context, key string, status int, contentType string, body []byte) (err error) {
+	ctx, op := s.begin(ctx, "SaveIdempotencyResponse")
+	defer op.end(&err)
+
+	update := bson.M{"$set": bson.M{
+		"status":      status,
+		"contentType": contentType,
+		"body":        body,
+	}}
+
+	_, err = s.idempotencyCollection().UpdateOne(ctx, bson.M{"_id": key}, update)
+	if err != nil {
+		s.log(ctx).Error("Ошибка при сохранении ответа для ключа идемпотентности", err)
+		return fmt.Errorf("ошибка при сохранении ответа для ключа идемпотентности: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey Освобождает ключ, чтобы запрос можно было повторить
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key string) (err error) {
+	ctx, op := s.begin(ctx, "ReleaseIdempotencyKey")
+	defer op.end(&err)
+
+	_, err = s.idempotencyCollection().DeleteOne(ctx, bson.M{"_id": key})
+	if err != nil {
+		s.log(ctx).Error("Ошибка при удалении ключа идемпотентности", err)
+		return fmt.Errorf("ошибка при удалении ключа идемпотентности: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) idempotencyCollection() *mongo.Collection {
+	return s.Collection(s.cfg.Idempotency.CollectionName)
+}
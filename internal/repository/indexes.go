@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sort"
+	"strings"
+	"zatrasz75/Ads_service/models"
+	"zatrasz75/Ads_service/pkg/search"
+)
+
+// Логические имена коллекций, реальные имена берутся из конфигурации
+const (
+	collectionAds         = "ads"
+	collectionIdempotency = "idempotency"
+)
+
+// Состояния индекса относительно реестра
+const (
+	IndexOK        = "ok"
+	IndexMissing   = "missing"
+	IndexChanged   = "changed"
+	IndexUnmanaged = "unmanaged"
+)
+
+// IndexSpec Описание индекса, которым управляет приложение
+type IndexSpec struct {
+	Collection string
+	Name       string
+	Keys       bson.D
+	Unique     bool
+	// ExpireAfter Время жизни документа в секундах для TTL индекса, nil для обычного индекса
+	ExpireAfter *int32
+	// Параметры текстового индекса
+	Weights          bson.D
+	DefaultLanguage  string
+	LanguageOverride string
+}
+
+func ttl(seconds int32) *int32 {
+	return &seconds
+}
+
+// indexRegistry Индексы, которые должны существовать в базе.
+// Составные индексы с _id соответствуют сортировкам списка объявлений и выгрузки,
+// обратный порядок сортировки обслуживается тем же индексом.
+var indexRegistry = []IndexSpec{
+	{
+		Collection: collectionAds,
+		Name:       "price_1__id_1",
+		Keys:       bson.D{{Key: "price", Value: 1}, {Key: "_id", Value: 1}},
+	},
+	{
+		Collection: collectionAds,
+		Name:       "creation_1__id_1",
+		Keys:       bson.D{{Key: "creation", Value: 1}, {Key: "_id", Value: 1}},
+	},
+	{
+		Collection:  collectionAds,
+		Name:        "expiresAt_ttl",
+		Keys:        bson.D{{Key: "expiresAt", Value: 1}},
+		ExpireAfter: ttl(0),
+	},
+	{
+		Collection:       collectionAds,
+		Name:             "name_description_text",
+		Keys:             bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+		Weights:          bson.D{{Key: "name", Value: nameWeight}, {Key: "description", Value: descriptionWeight}},
+		DefaultLanguage:  search.Russian,
+		LanguageOverride: "lang",
+	},
+	{
+		Collection: collectionAds,
+		Name:       "location_2dsphere",
+		Keys:       bson.D{{Key: "location", Value: "2dsphere"}},
+	},
+	{
+		Collection:  collectionIdempotency,
+		Name:        "expiresAt_ttl",
+		Keys:        bson.D{{Key: "expiresAt", Value: 1}},
+		ExpireAfter: ttl(0),
+	},
+}
+
+// IndexStates Сравнивает индексы в базе с реестром
+func (s *Store) IndexStates(ctx context.Context) (_ []models.IndexState, err error) {
+	ctx, op := s.begin(ctx, "IndexStates")
+	defer op.end(&err)
+
+	var states []models.IndexState
+
+	for _, collection := range []string{collectionAds, collectionIdempotency} {
+		existing, err := s.listIndexes(ctx, collection)
+		if err != nil {
+			s.log(ctx).Error("Ошибка при получении списка индексов", err)
+			return nil, fmt.Errorf("ошибка при получении списка индексов %s: %w", collection, err)
+		}
+
+		for _, spec := range indexRegistry {
+			if spec.Collection != collection {
+				continue
+			}
+			state := models.IndexState{
+				Collection: s.collectionName(collection),
+				Name:       spec.Name,
+				Spec:       spec.signature(),
+				State:      IndexOK,
+			}
+			actual, ok := existing[spec.Name]
+			switch {
+			case !ok:
+				state.State = IndexMissing
+			case actual != state.Spec:
+				state.State = IndexChanged
+				state.Actual = actual
+			default:
+				state.Actual = actual
+			}
+			delete(existing, spec.Name)
+			states = append(states, state)
+		}
+
+		// Индексы, которых нет в реестре, не удаляются: они могли быть созданы вручную
+		names := make([]string, 0, len(existing))
+		for name := range existing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if name == "_id_" {
+				continue
+			}
+			states = append(states, models.IndexState{
+				Collection: s.collectionName(collection),
+				Name:       name,
+				State:      IndexUnmanaged,
+				Actual:     existing[name],
+			})
+		}
+	}
+
+	return states, nil
+}
+
+// EnsureIndexes Приводит индексы в базе к реестру: создаёт отсутствующие и пересоздаёт изменённые.
+// В режиме dryRun только записывает в лог, что было бы изменено.
+func (s *Store) EnsureIndexes(ctx context.Context, dryRun bool) (err error) {
+	ctx, op := s.begin(ctx, "EnsureIndexes")
+	defer op.end(&err)
+
+	states, err := s.IndexStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	specs := make(map[string]IndexSpec, len(indexRegistry))
+	for _, spec := range indexRegistry {
+		specs[stateKey(s.collectionName(spec.Collection), spec.Name)] = spec
+	}
+
+	changes := 0
+	for _, state := range states {
+		spec := specs[stateKey(state.Collection, state.Name)]
+		switch state.State {
+		case IndexMissing:
+			changes++
+			if dryRun {
+				s.log(ctx).Info("[dry-run] будет создан индекс %s.%s %s", state.Collection, state.Name, state.Spec)
+				continue
+			}
+			s.log(ctx).Info("Создание индекса %s.%s %s", state.Collection, state.Name, state.Spec)
+			if err = s.createIndex(ctx, spec); err != nil {
+				return err
+			}
+		case IndexChanged:
+			changes++
+			if dryRun {
+				s.log(ctx).Info("[dry-run] будет пересоздан индекс %s.%s: %s -> %s", state.Collection, state.Name, state.Actual, state.Spec)
+				continue
+			}
+			s.log(ctx).Info("Пересоздание индекса %s.%s: %s -> %s", state.Collection, state.Name, state.Actual, state.Spec)
+			if _, err = s.collection(spec.Collection).Indexes().DropOne(ctx, spec.Name); err != nil {
+				s.log(ctx).Error("Ошибка при удалении индекса", err)
+				return fmt.Errorf("ошибка при удалении индекса %s: %w", spec.Name, err)
+			}
+			if err = s.createIndex(ctx, spec); err != nil {
+				return err
+			}
+		case IndexUnmanaged:
+			s.log(ctx).Warn("Индекс %s.%s отсутствует в реестре и оставлен без изменений", state.Collection, state.Name)
+		}
+	}
+
+	if changes == 0 {
+		s.log(ctx).Info("Индексы соответствуют реестру")
+	}
+	return nil
+}
+
+func stateKey(collection, name string) string {
+	return collection + "." + name
+}
+
+func (s *Store) createIndex(ctx context.Context, spec IndexSpec) error {
+	_, err := s.collection(spec.Collection).Indexes().CreateOne(ctx, spec.model())
+	if err != nil {
+		s.log(ctx).Error("Ошибка при создании индекса", err)
+		return fmt.Errorf("ошибка при создании индекса %s: %w", spec.Name, err)
+	}
+	return nil
+}
+
+// listIndexes Возвращает отпечатки существующих индексов коллекции по именам
+func (s *Store) listIndexes(ctx context.Context, collection string) (map[string]string, error) {
+	cursor, err := s.collection(collection).Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	existing := make(map[string]string)
+	for cursor.Next(ctx) {
+		var idx struct {
+			Name             string `bson:"name"`
+			Key              bson.D `bson:"key"`
+			Unique           bool   `bson:"unique"`
+			ExpireAfter      *int32 `bson:"expireAfterSeconds"`
+			Weights          bson.D `bson:"weights"`
+			DefaultLanguage  string `bson:"default_language"`
+			LanguageOverride string `bson:"language_override"`
+		}
+		if err = cursor.Decode(&idx); err != nil {
+			return nil, err
+		}
+		spec := IndexSpec{
+			Name:             idx.Name,
+			Keys:             idx.Key,
+			Unique:           idx.Unique,
+			ExpireAfter:      idx.ExpireAfter,
+			Weights:          idx.Weights,
+			DefaultLanguage:  idx.DefaultLanguage,
+			LanguageOverride: idx.LanguageOverride,
+		}
+		existing[idx.Name] = spec.signature()
+	}
+
+	return existing, cursor.Err()
+}
+
+// model Преобразует описание в модель индекса драйвера
+func (spec IndexSpec) model() mongo.IndexModel {
+	opts := options.Index().SetName(spec.Name)
+	if spec.Unique {
+		opts.SetUnique(true)
+	}
+	if spec.ExpireAfter != nil {
+		opts.SetExpireAfterSeconds(*spec.ExpireAfter)
+	}
+	if len(spec.Weights) > 0 {
+		opts.SetWeights(spec.Weights)
+	}
+	if spec.DefaultLanguage != "" {
+		opts.SetDefaultLanguage(spec.DefaultLanguage)
+	}
+	if spec.LanguageOverride != "" {
+		opts.SetLanguageOverride(spec.LanguageOverride)
+	}
+	return mongo.IndexModel{Keys: spec.Keys, Options: opts}
+}
+
+// isText Сообщает, является ли индекс текстовым. MongoDB хранит ключи текстового индекса
+// как {_fts: "text", _ftsx: 1}, а поля и их веса в weights.
+func (spec IndexSpec) isText() bool {
+	for _, k := range spec.Keys {
+		if k.Value == "text" || k.Key == "_fts" {
+			return true
+		}
+	}
+	return false
+}
+
+// signature Возвращает отпечаток индекса для сравнения описания с индексом в базе
+func (spec IndexSpec) signature() string {
+	var parts []string
+
+	if spec.isText() {
+		weights := make([]string, 0, len(spec.Weights))
+		for _, w := range spec.Weights {
+			weights = append(weights, w.Key+":"+normalizeIndexValue(w.Value))
+		}
+		// Порядок весов в базе не сохраняется
+		sort.Strings(weights)
+		parts = append(parts, "text("+strings.Join(weights, ",")+")")
+		language := spec.DefaultLanguage
+		if language == "" {
+			language = "english"
+		}
+		override := spec.LanguageOverride
+		if override == "" {
+			override = "language"
+		}
+		parts = append(parts, "language:"+language, "languageOverride:"+override)
+	} else {
+		keys := make([]string, 0, len(spec.Keys))
+		for _, k := range spec.Keys {
+			keys = append(keys, k.Key+":"+normalizeIndexValue(k.Value))
+		}
+		parts = append(parts, "{"+strings.Join(keys, ",")+"}")
+	}
+
+	if spec.Unique {
+		parts = append(parts, "unique")
+	}
+	if spec.ExpireAfter != nil {
+		parts = append(parts, fmt.Sprintf("ttl:%ds", *spec.ExpireAfter))
+	}
+	return strings.Join(parts, " ")
+}
+
+// normalizeIndexValue Приводит числовые значения ключей к одному виду: база может вернуть int32, int64 или double
+func normalizeIndexValue(v interface{}) string {
+	switch n := v.(type) {
+	case int:
+		return fmt.Sprint(n)
+	case int32:
+		return fmt.Sprint(n)
+	case int64:
+		return fmt.Sprint(n)
+	case float64:
+		return fmt.Sprint(n)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// collectionName Возвращает имя коллекции из конфигурации по логическому имени
+func (s *Store) collectionName(collection string) string {
+	if collection == collectionIdempotency {
+		return s.cfg.Idempotency.CollectionName
+	}
+	return s.cfg.Mongo.CollectionName
+}
+
+func (s *Store) collection(collection string) *mongo.Collection {
+	return s.Collection(s.collectionName(collection))
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"testing"
+	"zatrasz75/Ads_service/models"
+)
+
+func TestIndexSpec_signature(t *testing.T) {
+	// Индексы в том виде, в каком их возвращает listIndexes
+	existing := map[string]IndexSpec{
+		"price_1__id_1": {
+			Keys: bson.D{{Key: "price", Value: int32(1)}, {Key: "_id", Value: int32(1)}},
+		},
+		"expiresAt_ttl": {
+			Keys:        bson.D{{Key: "expiresAt", Value: int32(1)}},
+			ExpireAfter: ttl(0),
+		},
+		"name_description_text": {
+			Keys:             bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}},
+			Weights:          bson.D{{Key: "description", Value: int32(1)}, {Key: "name", Value: int32(3)}},
+			DefaultLanguage:  "russian",
+			LanguageOverride: "lang",
+		},
+	}
+
+	for _, spec := range indexRegistry {
+		actual, ok := existing[spec.Name]
+		if !ok || spec.Collection != collectionAds {
+			continue
+		}
+		if got, want := actual.signature(), spec.signature(); got != want {
+			t.Errorf("%s: отпечаток индекса из базы %q не совпадает с реестром %q", spec.Name, got, want)
+		}
+	}
+
+	changed := existing["price_1__id_1"]
+	changed.Unique = true
+	if changed.signature() == indexRegistry[0].signature() {
+		t.Error("Изменённый индекс не должен совпадать с реестром")
+	}
+}
+
+func TestStore_EnsureIndexes(t *testing.T) {
+	s := testStore(t)
+	ctx := context.Background()
+
+	states := func() map[string]models.IndexState {
+		t.Helper()
+		list, err := s.IndexStates(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byName := make(map[string]models.IndexState, len(list))
+		for _, st := range list {
+			byName[stateKey(st.Collection, st.Name)] = st
+		}
+		return byName
+	}
+	count := func(state string) int {
+		n := 0
+		for _, st := range states() {
+			if st.State == state {
+				n++
+			}
+		}
+		return n
+	}
+
+	// В новых коллекциях нет ни одного индекса из реестра, dry-run ничего не меняет
+	if got := count(IndexMissing); got != len(indexRegistry) {
+		t.Fatalf("отсутствующих индексов %d, ожидалось %d", got, len(indexRegistry))
+	}
+	if err := s.EnsureIndexes(ctx, true); err != nil {
+		t.Fatalf("EnsureIndexes dry-run: %v", err)
+	}
+	if got := count(IndexMissing); got != len(indexRegistry) {
+		t.Errorf("после dry-run отсутствующих индексов %d, ожидалось %d", got, len(indexRegistry))
+	}
+
+	if err := s.EnsureIndexes(ctx, false); err != nil {
+		t.Fatalf("EnsureIndexes: %v", err)
+	}
+	if got := count(IndexOK); got != len(indexRegistry) {
+		t.Fatalf("соответствующих реестру индексов %d, ожидалось %d: %v", got, len(indexRegistry), states())
+	}
+
+	// Изменённый индекс пересоздаётся, индекс вне реестра остаётся
+	ads := s.collection(collectionAds)
+	if _, err := ads.Indexes().DropOne(ctx, "price_1__id_1"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ads.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "price", Value: -1}, {Key: "_id", Value: 1}}, Options: options.Index().SetName("price_1__id_1")},
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetName("name_1")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := stateKey(s.collectionName(collectionAds), "price_1__id_1")
+	if st := states()[key]; st.State != IndexChanged {
+		t.Fatalf("состояние изменённого индекса %q", st.State)
+	}
+
+	if err = s.EnsureIndexes(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if st := states()[key]; st.State != IndexChanged {
+		t.Errorf("dry-run пересоздал индекс: состояние %q", st.State)
+	}
+
+	if err = s.EnsureIndexes(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	after := states()
+	if st := after[key]; st.State != IndexOK {
+		t.Errorf("индекс не пересоздан: состояние %q", st.State)
+	}
+	if st := after[stateKey(s.collectionName(collectionAds), "name_1")]; st.State != IndexUnmanaged {
+		t.Errorf("индекс вне реестра: состояние %q", st.State)
+	}
+}
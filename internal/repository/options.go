@@ -0,0 +1,13 @@
+package repository
+
+import "zatrasz75/Ads_service/pkg/metrics"
+
+// Option -.
+type Option func(*Store)
+
+// Metrics Метрики длительности и ошибок операций хранилища
+func Metrics(m *metrics.Metrics) Option {
+	return func(s *Store) {
+		s.metrics = m
+	}
+}
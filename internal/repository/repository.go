@@ -2,71 +2,138 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
 	"zatrasz75/Ads_service/configs"
 	"zatrasz75/Ads_service/models"
 	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/metrics"
 	"zatrasz75/Ads_service/pkg/mongo"
+	"zatrasz75/Ads_service/pkg/search"
+	"zatrasz75/Ads_service/pkg/tracing"
 )
 
 type Store struct {
 	l logger.LoggersInterface
 	*mongo.Mongo
-	cfg *configs.Config
+	cfg     *configs.Config
+	metrics *metrics.Metrics
+	// fallback Индекс поиска в памяти на случай отсутствия текстового индекса
+	fallback fallbackIndex
 }
 
-func New(mg *mongo.Mongo, l logger.LoggersInterface, cfg *configs.Config) *Store {
-	return &Store{l, mg, cfg}
+func New(mg *mongo.Mongo, l logger.LoggersInterface, cfg *configs.Config, opts ...Option) *Store {
+	s := &Store{l: l, Mongo: mg, cfg: cfg}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// operation Операция хранилища для трассировки и метрик
+type operation struct {
+	s      *Store
+	method string
+	start  time.Time
+	span   trace.Span
+}
+
+// begin Начинает операцию хранилища: операция трассировки repository.<method> и отсчёт длительности для метрик
+func (s *Store) begin(ctx context.Context, method string) (context.Context, *operation) {
+	ctx, span := tracing.Start(ctx, "repository."+method, attribute.String("db.system", "mongodb"), attribute.String("db.name", s.cfg.Mongo.DbName))
+	return ctx, &operation{s: s, method: method, start: time.Now(), span: span}
+}
+
+// end Завершает операцию, вызывается через defer с адресом возвращаемой ошибки
+func (o *operation) end(err *error) {
+	o.s.metrics.ObserveOperation(o.method, time.Since(o.start), *err)
+	tracing.End(o.span, *err)
+}
+
+// log Возвращает журнал запроса из ctx с идентификатором трассировки
+func (s *Store) log(ctx context.Context) logger.LoggersInterface {
+	return tracing.Logger(ctx, logger.FromContext(ctx, s.l))
 }
 
 // GetListPost Получения списка объявлений
-func (s *Store) GetListPost(page int, sortField, sortOrder string) ([]models.Ads, error) {
-	// Определение количества документов на странице
-	const pageSize = 10
+func (s *Store) GetListPost(ctx context.Context, page int, sortField, sortOrder string) ([]models.Ads, error) {
+	return s.FindPosts(ctx, page, models.ListFilter{SortField: sortField, SortOrder: sortOrder})
+}
+
+// listPageSize Количество объявлений на странице списка
+const listPageSize = 10
 
-	// Создание фильтра для поиска документов
-	filter := bson.M{}
+// FindPosts Получения страницы списка объявлений с учётом фильтра
+func (s *Store) FindPosts(ctx context.Context, page int, filter models.ListFilter) (_ []models.Ads, err error) {
+	ctx, op := s.begin(ctx, "FindPosts")
+	defer op.end(&err)
 
-	// Преобразование sortOrder в числовое значение для сортировки
-	var sortOrderValue int
-	if sortOrder == "asc" {
-		sortOrderValue = 1
-	} else if sortOrder == "desc" {
-		sortOrderValue = -1
-	} else {
-		return nil, fmt.Errorf("некорректное значение sortOrder: %s", sortOrder)
+	var posts []models.Ads
+	err = s.eachListItem(ctx, filter, int64(listPageSize*(page-1)), listPageSize, func(ad models.Ads) error {
+		posts = append(posts, ad)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return posts, nil
+}
 
-	// Создание опций для сортировки и пагинации
-	opts := options.Find().SetSort(bson.M{sortField: sortOrderValue}).SetSkip(int64(pageSize * (page - 1))).SetLimit(pageSize)
+// StreamPosts Последовательно передаёт в fn объявления страницы списка, читая их курсором,
+// чтобы ответ можно было отправлять, не собирая страницу целиком
+func (s *Store) StreamPosts(ctx context.Context, page int, filter models.ListFilter, fn func(models.Ads) error) (err error) {
+	ctx, op := s.begin(ctx, "StreamPosts")
+	defer op.end(&err)
 
+	return s.eachListItem(ctx, filter, int64(listPageSize*(page-1)), listPageSize, fn)
+}
+
+// eachListItem Передаёт в fn объявления, подходящие под фильтр, читая их курсором.
+// Нулевой limit означает выборку без ограничения.
+func (s *Store) eachListItem(ctx context.Context, filter models.ListFilter, skip, limit int64, fn func(models.Ads) error) error {
 	// Выполнение поиска документов в коллекции
-	cursor, err := s.M.Database(s.cfg.Mongo.DbName).Collection(s.cfg.Mongo.CollectionName).Find(context.Background(), filter, opts)
+	cursor, err := s.listCursor(ctx, filter, skip, limit)
 	if err != nil {
-		s.l.Error("Ошибка при поиске объявлений", err)
-		return nil, fmt.Errorf("ошибка при поиске объявлений: %w", err)
+		s.log(ctx).Error("Ошибка при поиске объявлений", err)
+		return fmt.Errorf("ошибка при поиске объявлений: %w", err)
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
-	// Декодирование результатов в срез структур models.Ads
-	var posts []models.Ads
-	if err = cursor.All(context.Background(), &posts); err != nil {
-		s.l.Error("Ошибка при декодировании результатов", err)
-		return nil, fmt.Errorf("ошибка при декодировании результатов: %w", err)
+	for cursor.Next(ctx) {
+		ad, err := decodeListItem(cursor)
+		if err != nil {
+			s.log(ctx).Error("Ошибка при декодировании результатов", err)
+			return fmt.Errorf("ошибка при декодировании результатов: %w", err)
+		}
+		if err = fn(ad); err != nil {
+			return err
+		}
 	}
-
-	return posts, nil
+	if err = cursor.Err(); err != nil {
+		s.log(ctx).Error("Ошибка при чтении курсора", err)
+		return fmt.Errorf("ошибка при чтении курсора: %w", err)
+	}
+	return nil
 }
 
 // GetSpecificPost Получения конкретного объявления
-func (s *Store) GetSpecificPost(id string) (models.Ads, error) {
+func (s *Store) GetSpecificPost(ctx context.Context, id string) (_ models.Ads, err error) {
+	ctx, op := s.begin(ctx, "GetSpecificPost")
+	defer op.end(&err)
+
 	// Преобразование строкового ID в ObjectID
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		s.l.Error("Не удалось преобразовать строковый ID в ObjectID", err)
+		s.log(ctx).Error("Не удалось преобразовать строковый ID в ObjectID", err)
 		return models.Ads{}, fmt.Errorf("не удалось преобразовать строковый ID в ObjectID: %w", err)
 	}
 
@@ -75,9 +142,9 @@ func (s *Store) GetSpecificPost(id string) (models.Ads, error) {
 
 	// Выполнение поиска документа в коллекции
 	var result models.Ads
-	err = s.M.Database(s.cfg.Mongo.DbName).Collection(s.cfg.Mongo.CollectionName).FindOne(context.Background(), filter).Decode(&result)
+	err = s.Collection("").FindOne(ctx, filter).Decode(&result)
 	if err != nil {
-		s.l.Error("Ошибка при поиске объявления по ID", err)
+		s.log(ctx).Error("Ошибка при поиске объявления по ID", err)
 		return models.Ads{}, fmt.Errorf("ошибка при поиске объявления по ID: %w", err)
 	}
 
@@ -85,19 +152,14 @@ func (s *Store) GetSpecificPost(id string) (models.Ads, error) {
 }
 
 // AddPost Добавляет новую запись
-func (s *Store) AddPost(ads models.Ads) (string, error) {
-	// Создание нового документа для MongoDB
-	newAd := bson.M{
-		"name":        ads.Name,
-		"description": ads.Description,
-		"price":       ads.Price,
-		"creation":    ads.Creation,
-	}
+func (s *Store) AddPost(ctx context.Context, ads models.Ads) (_ string, err error) {
+	ctx, op := s.begin(ctx, "AddPost")
+	defer op.end(&err)
 
 	// Добавление нового документа в коллекцию
-	insertResult, err := s.M.Database(s.cfg.Mongo.DbName).Collection(s.cfg.Mongo.CollectionName).InsertOne(context.Background(), newAd)
+	insertResult, err := s.Collection("").InsertOne(ctx, adDocument(ads))
 	if err != nil {
-		s.l.Error("Ошибка при добавлении нового объявления: %v", err)
+		s.log(ctx).Error("Ошибка при добавлении нового объявления: %v", err)
 		return "", err
 	}
 
@@ -106,10 +168,231 @@ func (s *Store) AddPost(ads models.Ads) (string, error) {
 
 	objectID, ok := id.(primitive.ObjectID)
 	if !ok {
-		s.l.Debug("Не удалось преобразовать ID в ObjectID")
+		s.log(ctx).Debug("Не удалось преобразовать ID в ObjectID")
 		return "", fmt.Errorf("не удалось преобразовать ID в ObjectID")
 	}
 
+	s.addToFallback(objectID.Hex(), ads)
+
 	// Преобразование ObjectID в строку
 	return objectID.Hex(), nil
 }
+
+// AddPosts Добавляет несколько записей одним запросом.
+// Запись выполняется без упорядочивания, поэтому ошибка одной записи не мешает добавлению остальных.
+// Заданный ID объявления сохраняется, например при загрузке ранее выгруженных объявлений,
+// объявление с некорректным или уже занятым ID не добавляется.
+func (s *Store) AddPosts(ctx context.Context, ads []models.Ads) (_ []models.BatchItemResult, err error) {
+	ctx, op := s.begin(ctx, "AddPosts")
+	defer op.end(&err)
+
+	results := make([]models.BatchItemResult, len(ads))
+
+	// ID генерируются заранее, чтобы сопоставить их с позициями в пакете
+	docs := make([]interface{}, 0, len(ads))
+	positions := make([]int, 0, len(ads))
+	for i, ad := range ads {
+		results[i].Index = i
+		objectID := primitive.NewObjectID()
+		if ad.ID != "" {
+			if objectID, err = primitive.ObjectIDFromHex(ad.ID); err != nil {
+				results[i].Error = fmt.Sprintf("некорректный id %q", ad.ID)
+				continue
+			}
+		}
+		doc := adDocument(ad)
+		doc["_id"] = objectID
+		docs = append(docs, doc)
+		positions = append(positions, i)
+		results[i].ID = objectID.Hex()
+	}
+	if len(docs) == 0 {
+		return results, nil
+	}
+
+	_, err = s.Collection("").InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil {
+		var bulkErr mongodriver.BulkWriteException
+		if !errors.As(err, &bulkErr) || len(bulkErr.WriteErrors) == 0 {
+			s.log(ctx).Error("Ошибка при пакетном добавлении объявлений", err)
+			return nil, fmt.Errorf("ошибка при пакетном добавлении объявлений: %w", err)
+		}
+		for _, we := range bulkErr.WriteErrors {
+			if we.Index >= 0 && we.Index < len(positions) {
+				i := positions[we.Index]
+				results[i].ID = ""
+				results[i].Error = we.Message
+			}
+		}
+		s.log(ctx).Warn("Пакетное добавление объявлений завершилось с ошибками: %d из %d", len(bulkErr.WriteErrors), len(ads))
+	}
+
+	for i, res := range results {
+		if res.Error == "" {
+			s.addToFallback(res.ID, ads[i])
+		}
+	}
+	return results, nil
+}
+
+// adDocument Создаёт документ MongoDB для нового объявления. ID документа задаёт вызывающий код,
+// без него MongoDB назначает новый.
+func adDocument(ad models.Ads) bson.M {
+	doc := bson.M{
+		"name":        ad.Name,
+		"description": ad.Description,
+		"price":       ad.Price,
+		"creation":    ad.Creation,
+		"currency":    withDefault(ad.Currency, models.DefaultCurrency),
+		"status":      withDefault(ad.Status, models.StatusActive),
+		"lang":        search.DetectLanguage(ad.Name + " " + ad.Description),
+	}
+	if ad.Owner != "" {
+		doc["owner"] = ad.Owner
+	}
+	if ad.Location != nil {
+		doc["location"] = ad.Location
+	}
+	if ad.ExpiresAt != nil {
+		doc["expiresAt"] = ad.ExpiresAt
+	}
+	return doc
+}
+
+// GetPostsByIDs Получения нескольких объявлений по списку ID.
+// Некорректные и отсутствующие ID пропускаются.
+func (s *Store) GetPostsByIDs(ctx context.Context, ids []string) (_ []models.Ads, err error) {
+	ctx, op := s.begin(ctx, "GetPostsByIDs")
+	defer op.end(&err)
+
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			s.log(ctx).Debug("Пропущен некорректный ID %s", id)
+			continue
+		}
+		objectIDs = append(objectIDs, objectID)
+	}
+	if len(objectIDs) == 0 {
+		return []models.Ads{}, nil
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": objectIDs}}
+
+	cursor, err := s.Collection("").Find(ctx, filter)
+	if err != nil {
+		s.log(ctx).Error("Ошибка при поиске объявлений по списку ID", err)
+		return nil, fmt.Errorf("ошибка при поиске объявлений по списку ID: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	posts := make([]models.Ads, 0, len(objectIDs))
+	if err = cursor.All(ctx, &posts); err != nil {
+		s.log(ctx).Error("Ошибка при декодировании результатов", err)
+		return nil, fmt.Errorf("ошибка при декодировании результатов: %w", err)
+	}
+
+	return posts, nil
+}
+
+// ExportPosts Последовательно передаёт в fn все объявления, подходящие под фильтр.
+// Документы читаются курсором, поэтому выборка целиком в память не загружается.
+func (s *Store) ExportPosts(ctx context.Context, filter models.ListFilter, fn func(models.Ads) error) (err error) {
+	ctx, op := s.begin(ctx, "ExportPosts")
+	defer op.end(&err)
+
+	return s.eachListItem(ctx, filter, 0, 0, fn)
+}
+
+// listCursor Открывает курсор по объявлениям с учётом фильтра, сортировки и пагинации.
+// Нулевой limit означает выборку без ограничения.
+func (s *Store) listCursor(ctx context.Context, filter models.ListFilter, skip, limit int64) (*mongodriver.Cursor, error) {
+	collection := s.Collection("")
+
+	// Создание сортировки, _id делает порядок стабильным при одинаковых значениях поля
+	var sort bson.D
+	if filter.SortField != "" {
+		order, err := sortOrderValue(filter.SortOrder)
+		if err != nil {
+			return nil, err
+		}
+		sortField := filter.SortField
+		if sortField == "distance" && filter.Near == nil {
+			return nil, fmt.Errorf("сортировка по расстоянию возможна только с параметром near")
+		}
+		sort = bson.D{{Key: sortField, Value: order}, {Key: "_id", Value: order}}
+	}
+
+	if filter.Near == nil {
+		// Создание опций для сортировки и пагинации
+		opts := options.Find().SetSkip(skip)
+		if sort != nil {
+			opts.SetSort(sort)
+		}
+		if limit > 0 {
+			opts.SetLimit(limit)
+		}
+		return collection.Find(ctx, bson.M{}, opts)
+	}
+
+	// Поиск рядом с точкой: $geoNear возвращает документы по возрастанию расстояния
+	// и записывает расстояние в метрах в поле distance
+	geoNear := bson.M{
+		"near":          bson.M{"type": "Point", "coordinates": bson.A{filter.Near.Lon, filter.Near.Lat}},
+		"distanceField": "distance",
+		"key":           "location",
+		"spherical":     true,
+	}
+	if filter.Near.RadiusKm > 0 {
+		geoNear["maxDistance"] = filter.Near.RadiusKm * 1000
+	}
+
+	pipeline := mongodriver.Pipeline{{{Key: "$geoNear", Value: geoNear}}}
+	if sort != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
+	}
+	if skip > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: skip}})
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+	return collection.Aggregate(ctx, pipeline)
+}
+
+// decodeListItem Декодирует объявление из курсора списка, переводя расстояние из метров в километры
+func decodeListItem(cursor *mongodriver.Cursor) (models.Ads, error) {
+	var item struct {
+		models.Ads `bson:",inline"`
+		Distance   *float64 `bson:"distance"`
+	}
+	if err := cursor.Decode(&item); err != nil {
+		return models.Ads{}, err
+	}
+	if item.Distance != nil {
+		km := *item.Distance / 1000
+		item.Ads.DistanceKm = &km
+	}
+	return item.Ads, nil
+}
+
+// withDefault Возвращает значение по умолчанию для пустой строки
+func withDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// sortOrderValue Преобразование sortOrder в числовое значение для сортировки
+func sortOrderValue(sortOrder string) (int, error) {
+	switch sortOrder {
+	case "asc":
+		return 1, nil
+	case "desc":
+		return -1, nil
+	default:
+		return 0, fmt.Errorf("некорректное значение sortOrder: %s", sortOrder)
+	}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,7 +14,7 @@ import (
 )
 
 func TestStore_AddPost_GetSpecificPost(t *testing.T) {
-	l := logger.NewLogger()
+	l := logger.Discard()
 
 	// Получаем текущий рабочий каталог
 	cwd, err := os.Getwd()
@@ -29,7 +30,7 @@ func TestStore_AddPost_GetSpecificPost(t *testing.T) {
 	if err != nil {
 		l.Fatal("ошибка при разборе конфигурационного файла", err)
 	}
-	mg, err := mongo.New(cfg.Mongo.ConnStr, l, mongo.OptionSet(cfg.Mongo.ConnAttempts, cfg.Mongo.ConnTimeout, cfg.Mongo.DbName, cfg.Mongo.CollectionName))
+	mg, err := mongo.New(cfg.Mongo.ConnStr.Value(), l, mongo.OptionSet(cfg.Mongo.ConnAttempts, cfg.Mongo.ConnTimeout, cfg.Mongo.DbName, cfg.Mongo.CollectionName))
 	if err != nil {
 		l.Fatal("нет соединения с базой данных", err)
 	}
@@ -46,7 +47,7 @@ func TestStore_AddPost_GetSpecificPost(t *testing.T) {
 	}
 
 	// Вызов метода AddPost
-	id, err := repo.AddPost(ad)
+	id, err := repo.AddPost(context.Background(), ad)
 	if err != nil {
 		t.Fatalf("Ошибка при добавлении объявления: %v", err)
 	}
@@ -59,7 +60,7 @@ func TestStore_AddPost_GetSpecificPost(t *testing.T) {
 	}
 
 	// Получение конкретного объявления по ID
-	result, err := repo.GetSpecificPost(id)
+	result, err := repo.GetSpecificPost(context.Background(), id)
 	if err != nil {
 		t.Fatalf("Ошибка при получении объявления по ID: %v", err)
 	}
@@ -73,7 +74,7 @@ func TestStore_AddPost_GetSpecificPost(t *testing.T) {
 }
 
 func TestStore_GetListPost_AddPost(t *testing.T) {
-	l := logger.NewLogger()
+	l := logger.Discard()
 
 	// Получаем текущий рабочий каталог
 	cwd, err := os.Getwd()
@@ -89,7 +90,7 @@ func TestStore_GetListPost_AddPost(t *testing.T) {
 	if err != nil {
 		l.Fatal("ошибка при разборе конфигурационного файла", err)
 	}
-	mg, err := mongo.New(cfg.Mongo.ConnStr, l, mongo.OptionSet(cfg.Mongo.ConnAttempts, cfg.Mongo.ConnTimeout, cfg.Mongo.DbName, cfg.Mongo.CollectionName))
+	mg, err := mongo.New(cfg.Mongo.ConnStr.Value(), l, mongo.OptionSet(cfg.Mongo.ConnAttempts, cfg.Mongo.ConnTimeout, cfg.Mongo.DbName, cfg.Mongo.CollectionName))
 	if err != nil {
 		l.Fatal("нет соединения с базой данных", err)
 	}
@@ -104,13 +105,13 @@ func TestStore_GetListPost_AddPost(t *testing.T) {
 	}
 
 	for _, ad := range ads {
-		_, err = repo.AddPost(ad)
+		_, err = repo.AddPost(context.Background(), ad)
 		if err != nil {
 			t.Fatalf("Ошибка при добавлении объявления: %v", err)
 		}
 	}
 
-	posts, err := repo.GetListPost(1, "creation", "asc")
+	posts, err := repo.GetListPost(context.Background(), 1, "creation", "asc")
 	if err != nil {
 		t.Fatalf("Ошибка при получении списка объявлений: %v", err)
 	}
@@ -122,3 +123,24 @@ func TestStore_GetListPost_AddPost(t *testing.T) {
 		t.Errorf("Полученные данные не соответствуют ожидаемым. Ожидалось минимум: %v, Получено: %v", ads, posts)
 	}
 }
+
+func TestAdDocument(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+	doc := adDocument(models.Ads{ID: "65f000000000000000000001", Name: "стол", Description: "дубовый", ExpiresAt: &expires})
+
+	// ID документа задаёт вызывающий код, незаполненные поля получают значения по умолчанию
+	if _, ok := doc["_id"]; ok {
+		t.Errorf("документ содержит _id: %v", doc)
+	}
+	if doc["currency"] != models.DefaultCurrency || doc["status"] != models.StatusActive || doc["lang"] == "" {
+		t.Errorf("не заданы значения по умолчанию: %v", doc)
+	}
+	if doc["expiresAt"] != &expires {
+		t.Errorf("expiresAt = %v", doc["expiresAt"])
+	}
+	for _, field := range []string{"owner", "location"} {
+		if _, ok := doc[field]; ok {
+			t.Errorf("документ содержит пустое поле %s", field)
+		}
+	}
+}
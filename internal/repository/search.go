@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sync"
+	"time"
+	"zatrasz75/Ads_service/models"
+	"zatrasz75/Ads_service/pkg/search"
+)
+
+// Веса полей при ранжировании, общие для текстового индекса MongoDB и индекса в памяти
+const (
+	nameWeight        = 3
+	descriptionWeight = 1
+)
+
+// searchPageSize Количество результатов поиска на странице
+const searchPageSize = 10
+
+// codeIndexNotFound Код ошибки MongoDB для запроса $text без текстового индекса
+const codeIndexNotFound = 27
+
+// fallbackRebuild Как часто индекс в памяти перестраивается по коллекции, чтобы учесть изменённые и удалённые объявления
+const fallbackRebuild = time.Minute
+
+// fallbackIndex Инвертированный индекс в памяти, по которому выполняется поиск,
+// пока в коллекции нет текстового индекса, например до выполнения EnsureIndexes
+type fallbackIndex struct {
+	mu      sync.Mutex
+	idx     *search.Index
+	builtAt time.Time
+}
+
+// SearchPosts Полнотекстовый поиск объявлений, результаты упорядочены по релевантности.
+// Язык запроса определяется по алфавиту и используется MongoDB для стемминга слов запроса.
+func (s *Store) SearchPosts(ctx context.Context, query string, page int) (_ []models.SearchResult, err error) {
+	ctx, op := s.begin(ctx, "SearchPosts")
+	defer op.end(&err)
+
+	filter := bson.M{"$text": bson.M{
+		"$search":   query,
+		"$language": search.DetectLanguage(query),
+	}}
+
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(searchPageSize * (page - 1))).
+		SetLimit(searchPageSize)
+
+	cursor, err := s.Collection("").Find(ctx, filter, opts)
+	if isTextIndexMissing(err) {
+		return s.searchFallback(ctx, query, page)
+	}
+	if err != nil {
+		s.log(ctx).Error("Ошибка при поиске объявлений", err)
+		return nil, fmt.Errorf("ошибка при поиске объявлений: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	results := make([]models.SearchResult, 0, searchPageSize)
+	if err = cursor.All(ctx, &results); err != nil {
+		s.log(ctx).Error("Ошибка при декодировании результатов", err)
+		return nil, fmt.Errorf("ошибка при декодировании результатов: %w", err)
+	}
+
+	return results, nil
+}
+
+// isTextIndexMissing Сообщает, что запрос $text отклонён из-за отсутствия текстового индекса
+func isTextIndexMissing(err error) bool {
+	var se mongodriver.ServerError
+	return errors.As(err, &se) && se.HasErrorCode(codeIndexNotFound)
+}
+
+// searchFallback Ищет объявления по индексу в памяти. Релевантность считается по TF-IDF
+// и не совпадает со значениями textScore MongoDB, но порядок результатов сопоставим.
+func (s *Store) searchFallback(ctx context.Context, query string, page int) ([]models.SearchResult, error) {
+	idx, err := s.fallbackSearchIndex(ctx)
+	if err != nil {
+		s.log(ctx).Error("Ошибка при построении индекса поиска в памяти", err)
+		return nil, fmt.Errorf("ошибка при построении индекса поиска в памяти: %w", err)
+	}
+
+	found := idx.Search(query)
+	skip := searchPageSize * (page - 1)
+	if skip >= len(found) {
+		return []models.SearchResult{}, nil
+	}
+	found = found[skip:min(skip+searchPageSize, len(found))]
+
+	ids := make([]primitive.ObjectID, 0, len(found))
+	for _, res := range found {
+		if id, err := primitive.ObjectIDFromHex(res.ID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	cursor, err := s.Collection("").Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		s.log(ctx).Error("Ошибка при поиске объявлений", err)
+		return nil, fmt.Errorf("ошибка при поиске объявлений: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var ads []models.Ads
+	if err = cursor.All(ctx, &ads); err != nil {
+		s.log(ctx).Error("Ошибка при декодировании результатов", err)
+		return nil, fmt.Errorf("ошибка при декодировании результатов: %w", err)
+	}
+	byID := make(map[string]models.Ads, len(ads))
+	for _, ad := range ads {
+		byID[ad.ID] = ad
+	}
+
+	// Порядок по релевантности, объявления, удалённые после построения индекса, пропускаются
+	results := make([]models.SearchResult, 0, len(found))
+	for _, res := range found {
+		if ad, ok := byID[res.ID]; ok {
+			results = append(results, models.SearchResult{Ads: ad, Score: res.Score})
+		}
+	}
+	return results, nil
+}
+
+// fallbackSearchIndex Возвращает индекс в памяти, при первом обращении и по истечении fallbackRebuild
+// индекс строится заново по всей коллекции
+func (s *Store) fallbackSearchIndex(ctx context.Context) (*search.Index, error) {
+	s.fallback.mu.Lock()
+	defer s.fallback.mu.Unlock()
+
+	if s.fallback.idx != nil && time.Since(s.fallback.builtAt) < fallbackRebuild {
+		return s.fallback.idx, nil
+	}
+
+	s.log(ctx).Warn("Текстовый индекс не найден, поиск выполняется по индексу в памяти")
+	cursor, err := s.Collection("").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"name": 1, "description": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	idx := search.NewIndex()
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID          primitive.ObjectID `bson:"_id"`
+			Name        string             `bson:"name"`
+			Description string             `bson:"description"`
+		}
+		if err = cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		idx.Add(doc.ID.Hex(), searchFields(doc.Name, doc.Description)...)
+	}
+	if err = cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	s.fallback.idx, s.fallback.builtAt = idx, time.Now()
+	return idx, nil
+}
+
+// addToFallback Добавляет новое объявление в индекс в памяти, если поиск уже работает по нему
+func (s *Store) addToFallback(id string, ad models.Ads) {
+	s.fallback.mu.Lock()
+	idx := s.fallback.idx
+	s.fallback.mu.Unlock()
+
+	if idx != nil {
+		idx.Add(id, searchFields(ad.Name, ad.Description)...)
+	}
+}
+
+func searchFields(name, description string) []search.Field {
+	return []search.Field{
+		{Text: name, Weight: nameWeight},
+		{Text: description, Weight: descriptionWeight},
+	}
+}
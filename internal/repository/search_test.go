@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"zatrasz75/Ads_service/models"
+)
+
+func TestStore_SearchPosts_fallback(t *testing.T) {
+	s := testStore(t)
+	ctx := context.Background()
+
+	// Коллекция создаётся без текстового индекса, поиск выполняется по индексу в памяти
+	now := time.Now().UTC()
+	_, err := s.AddPosts(ctx, []models.Ads{
+		{Name: "Велосипед горный", Description: "почти новый", Price: 100, Creation: now},
+		{Name: "Шкаф", Description: "подойдёт для велосипеда", Price: 50, Creation: now},
+		{Name: "Стол", Description: "дубовый", Price: 10, Creation: now},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := s.SearchPosts(ctx, "велосипед", 1)
+	if err != nil {
+		t.Fatalf("поиск без текстового индекса: %v", err)
+	}
+	if len(results) != 2 || results[0].Name != "Велосипед горный" {
+		t.Fatalf("результаты %+v: ожидались 2 объявления, первым совпадение в названии", results)
+	}
+
+	// Новое объявление находится без перестроения индекса
+	id, err := s.AddPost(ctx, models.Ads{Name: "Велосипед детский", Price: 20, Creation: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err = s.SearchPosts(ctx, "велосипед", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, res := range results {
+		found = found || res.ID == id
+	}
+	if !found {
+		t.Errorf("новое объявление %s не найдено: %+v", id, results)
+	}
+
+	if results, err = s.SearchPosts(ctx, "велосипед", 2); err != nil || len(results) != 0 {
+		t.Errorf("вторая страница: %+v, %v", results, err)
+	}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"zatrasz75/Ads_service/configs"
+	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/mongo"
+)
+
+// testStore Подключается к MongoDB из configs.yml и создаёт хранилище с отдельными коллекциями,
+// которые удаляются после теста. Если база недоступна, тест пропускается.
+func testStore(t *testing.T) *Store {
+	t.Helper()
+	l := logger.Discard()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := configs.NewConfig(filepath.Join(cwd, "..", "..", "configs", "configs.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	suffix := fmt.Sprintf("_test_%d", time.Now().UnixNano())
+	cfg.Mongo.CollectionName += suffix
+	cfg.Idempotency.CollectionName += suffix
+
+	mg, err := mongo.New(cfg.Mongo.ConnStr.Value(), l,
+		mongo.OptionSet(1, time.Second, cfg.Mongo.DbName, cfg.Mongo.CollectionName),
+		mongo.Timeouts(2*time.Second, 0),
+		mongo.ConnDeadline(3*time.Second),
+	)
+	if err != nil {
+		t.Skipf("MongoDB недоступна: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_ = mg.Collection(cfg.Mongo.CollectionName).Drop(ctx)
+		_ = mg.Collection(cfg.Idempotency.CollectionName).Drop(ctx)
+		_ = mg.Close(ctx)
+	})
+
+	return New(mg, l, cfg)
+}
@@ -1,12 +1,41 @@
 package storage
 
-import "zatrasz75/Ads_service/models"
+import (
+	"context"
+	"zatrasz75/Ads_service/models"
+)
 
 type RepositoryInterface interface {
 	// GetListPost Получения списка объявлений
-	GetListPost(page int, sortField, sortOrder string) ([]models.Ads, error)
+	GetListPost(ctx context.Context, page int, sortField, sortOrder string) ([]models.Ads, error)
+	// FindPosts Получения страницы списка объявлений с учётом фильтра
+	FindPosts(ctx context.Context, page int, filter models.ListFilter) ([]models.Ads, error)
+	// StreamPosts Последовательно передаёт в fn объявления страницы списка, читая их курсором
+	StreamPosts(ctx context.Context, page int, filter models.ListFilter, fn func(models.Ads) error) error
 	// GetSpecificPost Получения конкретного объявления
-	GetSpecificPost(id string) (models.Ads, error)
+	GetSpecificPost(ctx context.Context, id string) (models.Ads, error)
 	// AddPost Добавляет новую запись
-	AddPost(ads models.Ads) (string, error)
+	AddPost(ctx context.Context, ads models.Ads) (string, error)
+	// AddPosts Добавляет несколько записей одним запросом, возвращает результат по каждой записи
+	AddPosts(ctx context.Context, ads []models.Ads) ([]models.BatchItemResult, error)
+	// GetPostsByIDs Получения нескольких объявлений по списку ID
+	GetPostsByIDs(ctx context.Context, ids []string) ([]models.Ads, error)
+	// ExportPosts Последовательно передаёт в fn все объявления, подходящие под фильтр, читая их курсором
+	ExportPosts(ctx context.Context, filter models.ListFilter, fn func(models.Ads) error) error
+	// SearchPosts Полнотекстовый поиск объявлений, результаты упорядочены по релевантности
+	SearchPosts(ctx context.Context, query string, page int) ([]models.SearchResult, error)
+}
+
+type IdempotencyInterface interface {
+	// ReserveIdempotencyKey Резервирует ключ идемпотентности, возвращает существующую запись, если ключ уже занят
+	ReserveIdempotencyKey(ctx context.Context, key, requestHash string) (models.IdempotencyRecord, bool, error)
+	// SaveIdempotencyResponse Сохраняет ответ, полученный для ключа идемпотентности
+	SaveIdempotencyResponse(ctx context.Context, key string, status int, contentType string, body []byte) error
+	// ReleaseIdempotencyKey Освобождает ключ, чтобы запрос можно было повторить
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+}
+
+type IndexInterface interface {
+	// IndexStates Сравнивает индексы в базе с реестром индексов
+	IndexStates(ctx context.Context) ([]models.IndexState, error)
 }
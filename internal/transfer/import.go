@@ -0,0 +1,144 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+	"zatrasz75/Ads_service/internal/jobs"
+	"zatrasz75/Ads_service/models"
+)
+
+// _defaultBatchSize Размер пакета записи по умолчанию
+const _defaultBatchSize = 1000
+
+// Store Хранилище, в которое загружаются объявления
+type Store interface {
+	AddPosts(ctx context.Context, ads []models.Ads) ([]models.BatchItemResult, error)
+}
+
+// Importer Загружает объявления пакетами с теми же проверками, что и API. Используется маршрутом /admin/import
+// и командой import исполняемого файла.
+type Importer struct {
+	store       Store
+	batchSize   int
+	maxLifetime time.Duration
+	keepIDs     bool
+}
+
+// ImportOption -.
+type ImportOption func(*Importer)
+
+// BatchSize -.
+func BatchSize(size int) ImportOption {
+	return func(i *Importer) {
+		if size > 0 {
+			i.batchSize = size
+		}
+	}
+}
+
+// MaxLifetime Ограничение срока действия объявления, как api.max-lifetime
+func MaxLifetime(d time.Duration) ImportOption {
+	return func(i *Importer) {
+		i.maxLifetime = d
+	}
+}
+
+// KeepIDs Сохраняет ID объявлений из файла, без него объявления получают новые ID
+func KeepIDs(keep bool) ImportOption {
+	return func(i *Importer) {
+		i.keepIDs = keep
+	}
+}
+
+// NewImporter -.
+func NewImporter(store Store, opts ...ImportOption) *Importer {
+	i := &Importer{
+		store:     store,
+		batchSize: _defaultBatchSize,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// Job Возвращает фоновую задачу загрузки объявлений из reader
+func (i *Importer) Job(reader Reader) jobs.Func {
+	return func(ctx context.Context, p *jobs.Progress) error {
+		return i.Run(ctx, reader, p)
+	}
+}
+
+// Run Читает объявления и добавляет их пакетами, ошибки строк учитываются в задаче
+func (i *Importer) Run(ctx context.Context, reader Reader, p *jobs.Progress) error {
+	batch := make([]models.Ads, 0, i.batchSize)
+	lines := make([]int, 0, i.batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := i.store.AddPosts(ctx, batch)
+		if err != nil {
+			return err
+		}
+		succeeded := 0
+		for n, res := range results {
+			if res.Error != "" {
+				p.Fail(lines[n], errors.New(res.Error))
+				continue
+			}
+			succeeded++
+		}
+		p.Succeed(succeeded)
+		batch, lines = batch[:0], lines[:0]
+		return nil
+	}
+
+	now := time.Now()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ad, line, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var lineErr *LineError
+		if errors.As(err, &lineErr) {
+			p.Fail(lineErr.Line, errors.New(lineErr.Err))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = ad.Validate(i.maxLifetime); err != nil {
+			p.Fail(line, err)
+			continue
+		}
+		if ad.Price, err = models.RoundPrice(ad.Price); err != nil {
+			p.Fail(line, errors.New("не удалось округлить цену"))
+			continue
+		}
+		if ad.Creation.IsZero() {
+			ad.Creation = now
+		}
+		if !i.keepIDs {
+			ad.ID = ""
+		}
+
+		batch = append(batch, ad)
+		lines = append(lines, line)
+		if len(batch) >= i.batchSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
@@ -0,0 +1,92 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"zatrasz75/Ads_service/internal/jobs"
+	"zatrasz75/Ads_service/models"
+)
+
+// memStore Хранилище в памяти, запоминает размеры пакетов записи
+type memStore struct {
+	ads     []models.Ads
+	batches []int
+}
+
+func (m *memStore) AddPosts(_ context.Context, ads []models.Ads) ([]models.BatchItemResult, error) {
+	m.batches = append(m.batches, len(ads))
+	results := make([]models.BatchItemResult, len(ads))
+	for i, ad := range ads {
+		if ad.ID == "" {
+			ad.ID = fmt.Sprintf("%024x", len(m.ads)+1)
+		}
+		m.ads = append(m.ads, ad)
+		results[i] = models.BatchItemResult{Index: i, ID: ad.ID}
+	}
+	return results, nil
+}
+
+func TestImporter_Run(t *testing.T) {
+	const file = `{"name":"стол","price":100.456}
+не json
+{"name":"","price":50}
+{"name":"шкаф","price":10,"status":"deleted"}
+{"name":"стул","price":50}
+{"name":"полка","price":20}
+`
+	store := &memStore{}
+	reader, err := NewReader(strings.NewReader(file), FormatNDJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := jobs.Run(context.Background(), "import", NewImporter(store, BatchSize(2)).Job(reader))
+
+	if job.Status != jobs.StatusCompleted || job.Processed != 6 || job.Succeeded != 3 || job.Failed != 3 {
+		t.Fatalf("задача %+v", job)
+	}
+	var lines []int
+	for _, e := range job.Errors {
+		lines = append(lines, e.Line)
+	}
+	if fmt.Sprint(lines) != "[2 3 4]" {
+		t.Errorf("ошибки в строках %v, ожидались [2 3 4]", lines)
+	}
+	if fmt.Sprint(store.batches) != "[2 1]" {
+		t.Errorf("пакеты записи %v, ожидались [2 1]", store.batches)
+	}
+	if store.ads[0].Price != 100.46 || store.ads[0].Creation.IsZero() {
+		t.Errorf("объявление не подготовлено к записи: %+v", store.ads[0])
+	}
+}
+
+func TestImporter_KeepIDs(t *testing.T) {
+	files := map[string]string{
+		FormatNDJSON: `{"id":"65f000000000000000000001","name":"стол","price":100}
+{"id":"65f000000000000000000002","name":"стул","price":50}
+`,
+		FormatCSV: `id,name,price
+65f000000000000000000001,стол,100
+65f000000000000000000002,стул,50
+`,
+	}
+	for format, file := range files {
+		for _, keepIDs := range []bool{true, false} {
+			store := &memStore{}
+			reader, err := NewReader(strings.NewReader(file), format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			job := jobs.Run(context.Background(), "import", NewImporter(store, KeepIDs(keepIDs)).Job(reader))
+			if job.Status != jobs.StatusCompleted || job.Succeeded != 2 {
+				t.Fatalf("%s, keepIDs=%v: задача %+v", format, keepIDs, job)
+			}
+
+			kept := store.ads[0].ID == "65f000000000000000000001" && store.ads[1].ID == "65f000000000000000000002"
+			if kept != keepIDs {
+				t.Errorf("%s, keepIDs=%v: ID после загрузки %s, %s", format, keepIDs, store.ads[0].ID, store.ads[1].ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"zatrasz75/Ads_service/models"
+)
+
+// Поддерживаемые форматы выгрузки и загрузки объявлений
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// csvHeader Заголовок CSV файла
+var csvHeader = []string{"id", "name", "description", "price", "creation", "lat", "lon", "city", "address", "currency", "status", "owner", "expiresAt"}
+
+// maxLineSize Максимальная длина строки NDJSON
+const maxLineSize = 1024 * 1024
+
+// ErrUnknownFormat Неизвестный формат файла
+var ErrUnknownFormat = errors.New("неизвестный формат, допустимые значения: csv, ndjson")
+
+// ParseFormat Проверяет название формата, пустое значение означает NDJSON
+func ParseFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", FormatNDJSON, "jsonl":
+		return FormatNDJSON, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// FormatFromContentType Определяет формат по заголовку Content-Type
+func FormatFromContentType(contentType string) (string, bool) {
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	switch strings.ToLower(mediaType) {
+	case "text/csv", "application/csv":
+		return FormatCSV, true
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return FormatNDJSON, true
+	default:
+		return "", false
+	}
+}
+
+// ContentType Возвращает значение Content-Type для формата
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Writer Построчная запись объявлений
+type Writer interface {
+	// Write Записывает одно объявление
+	Write(ad models.Ads) error
+	// Flush Сбрасывает буферизированные данные
+	Flush() error
+}
+
+// NewWriter Создаёт Writer для указанного формата
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := &csvWriter{w: csv.NewWriter(w)}
+		if err := cw.w.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		return cw, nil
+	case FormatNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonWriter{bw: bw, enc: json.NewEncoder(bw)}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Write(ad models.Ads) error {
+	record := []string{
+		ad.ID,
+		ad.Name,
+		ad.Description,
+		strconv.FormatFloat(ad.Price, 'f', -1, 64),
+		ad.Creation.UTC().Format(time.RFC3339Nano),
+		"", "", "", "",
+		ad.Currency,
+		ad.Status,
+		ad.Owner,
+		"",
+	}
+	if loc := ad.Location; loc != nil {
+		record[5] = strconv.FormatFloat(loc.Lat, 'f', -1, 64)
+		record[6] = strconv.FormatFloat(loc.Lon, 'f', -1, 64)
+		record[7] = loc.City
+		record[8] = loc.Address
+	}
+	if ad.ExpiresAt != nil {
+		record[12] = ad.ExpiresAt.UTC().Format(time.RFC3339Nano)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	bw  *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(ad models.Ads) error {
+	// Encode добавляет перевод строки после каждого объекта
+	return n.enc.Encode(ad)
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return n.bw.Flush()
+}
+
+// LineError Ошибка разбора строки файла
+type LineError struct {
+	Line int    `json:"line"`
+	Err  string `json:"error"`
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("строка %d: %s", e.Line, e.Err)
+}
+
+// Reader Построчное чтение объявлений.
+// Next возвращает номер строки прочитанного объявления, *LineError для строки,
+// которую не удалось разобрать (чтение можно продолжить), и io.EOF в конце файла.
+type Reader interface {
+	Next() (models.Ads, int, error)
+}
+
+// NewReader Создаёт Reader для указанного формата
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.ReuseRecord = true
+		reader := &csvReader{r: cr}
+		if err := reader.readHeader(); err != nil {
+			return nil, err
+		}
+		return reader, nil
+	case FormatNDJSON:
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), maxLineSize)
+		return &ndjsonReader{sc: sc}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func (c *csvReader) readHeader() error {
+	header, err := c.r.Read()
+	if errors.Is(err, io.EOF) {
+		return &LineError{Line: 1, Err: "пустой файл, ожидался заголовок"}
+	}
+	if err != nil {
+		return &LineError{Line: 1, Err: err.Error()}
+	}
+
+	c.columns = make(map[string]int, len(header))
+	for i, name := range header {
+		c.columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"name", "price"} {
+		if _, ok := c.columns[required]; !ok {
+			return &LineError{Line: 1, Err: fmt.Sprintf("в заголовке отсутствует колонка %q", required)}
+		}
+	}
+	return nil
+}
+
+func (c *csvReader) field(record []string, name string) string {
+	i, ok := c.columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func (c *csvReader) Next() (models.Ads, int, error) {
+	record, err := c.r.Read()
+	if errors.Is(err, io.EOF) {
+		return models.Ads{}, 0, io.EOF
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return models.Ads{}, parseErr.StartLine, &LineError{Line: parseErr.StartLine, Err: parseErr.Err.Error()}
+		}
+		return models.Ads{}, 0, err
+	}
+	line, _ := c.r.FieldPos(0)
+
+	ad := models.Ads{
+		ID:          c.field(record, "id"),
+		Name:        c.field(record, "name"),
+		Description: c.field(record, "description"),
+		Currency:    c.field(record, "currency"),
+		Status:      c.field(record, "status"),
+		Owner:       c.field(record, "owner"),
+	}
+	if price := c.field(record, "price"); price != "" {
+		ad.Price, err = strconv.ParseFloat(price, 64)
+		if err != nil {
+			return models.Ads{}, line, &LineError{Line: line, Err: fmt.Sprintf("некорректная цена %q", price)}
+		}
+	}
+	if creation := c.field(record, "creation"); creation != "" {
+		ad.Creation, err = time.Parse(time.RFC3339Nano, creation)
+		if err != nil {
+			return models.Ads{}, line, &LineError{Line: line, Err: fmt.Sprintf("некорректная дата создания %q", creation)}
+		}
+	}
+	// Названия колонок приводятся к нижнему регистру при чтении заголовка
+	if expiresAt := c.field(record, "expiresat"); expiresAt != "" {
+		t, err := time.Parse(time.RFC3339Nano, expiresAt)
+		if err != nil {
+			return models.Ads{}, line, &LineError{Line: line, Err: fmt.Sprintf("некорректное время окончания %q", expiresAt)}
+		}
+		ad.ExpiresAt = &t
+	}
+
+	// Местоположение задаётся только парой координат, город и адрес без координат игнорируются
+	lat, lon := c.field(record, "lat"), c.field(record, "lon")
+	if lat != "" || lon != "" {
+		loc := &models.Location{City: c.field(record, "city"), Address: c.field(record, "address")}
+		loc.Lat, err = strconv.ParseFloat(lat, 64)
+		if err == nil {
+			loc.Lon, err = strconv.ParseFloat(lon, 64)
+		}
+		if err != nil {
+			return models.Ads{}, line, &LineError{Line: line, Err: fmt.Sprintf("некорректные координаты %q, %q", lat, lon)}
+		}
+		ad.Location = loc
+	}
+
+	return ad, line, nil
+}
+
+type ndjsonReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func (n *ndjsonReader) Next() (models.Ads, int, error) {
+	for n.sc.Scan() {
+		n.line++
+		text := strings.TrimSpace(n.sc.Text())
+		if text == "" {
+			continue
+		}
+
+		var ad models.Ads
+		if err := json.Unmarshal([]byte(text), &ad); err != nil {
+			return models.Ads{}, n.line, &LineError{Line: n.line, Err: "некорректный JSON: " + err.Error()}
+		}
+		return ad, n.line, nil
+	}
+
+	// Ошибка сканера (например, слишком длинная строка) не позволяет продолжить чтение
+	if err := n.sc.Err(); err != nil {
+		return models.Ads{}, n.line + 1, fmt.Errorf("строка %d: %w", n.line+1, err)
+	}
+	return models.Ads{}, n.line, io.EOF
+}
@@ -0,0 +1,114 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"zatrasz75/Ads_service/models"
+)
+
+func TestWriter_Reader_RoundTrip(t *testing.T) {
+	ct := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	expires := ct.Add(30 * 24 * time.Hour)
+	ads := []models.Ads{
+		{ID: "1", Name: "реклама 1", Description: "описание, с запятой", Price: 100.05, Creation: ct,
+			Currency: "USD", Status: models.StatusSold, Owner: "user-1", ExpiresAt: &expires,
+			Location: &models.Location{Lat: 55.7558, Lon: 37.6173, City: "Москва", Address: "Красная площадь, 1"}},
+		{ID: "2", Name: "реклама \"2\"", Description: "многострочное\nописание", Price: 200, Creation: ct.Add(time.Minute)},
+	}
+
+	for _, format := range []string{FormatCSV, FormatNDJSON} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: ошибка создания Writer: %v", format, err)
+		}
+		for _, ad := range ads {
+			if err = w.Write(ad); err != nil {
+				t.Fatalf("%s: ошибка записи: %v", format, err)
+			}
+		}
+		if err = w.Flush(); err != nil {
+			t.Fatalf("%s: ошибка сброса: %v", format, err)
+		}
+
+		r, err := NewReader(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: ошибка создания Reader: %v", format, err)
+		}
+		for i, want := range ads {
+			got, _, err := r.Next()
+			if err != nil {
+				t.Fatalf("%s: ошибка чтения объявления %d: %v", format, i, err)
+			}
+			if got.ID != want.ID || got.Name != want.Name || got.Description != want.Description || got.Price != want.Price || !got.Creation.Equal(want.Creation) {
+				t.Errorf("%s: Ожидалось: %v, Получено: %v", format, want, got)
+			}
+			if got.Currency != want.Currency || got.Status != want.Status || got.Owner != want.Owner {
+				t.Errorf("%s: Ожидалось currency, status, owner: %q, %q, %q, Получено: %q, %q, %q",
+					format, want.Currency, want.Status, want.Owner, got.Currency, got.Status, got.Owner)
+			}
+			if (want.ExpiresAt == nil) != (got.ExpiresAt == nil) || (want.ExpiresAt != nil && !got.ExpiresAt.Equal(*want.ExpiresAt)) {
+				t.Errorf("%s: Ожидалось expiresAt: %v, Получено: %v", format, want.ExpiresAt, got.ExpiresAt)
+			}
+			if (want.Location == nil) != (got.Location == nil) || (want.Location != nil && *got.Location != *want.Location) {
+				t.Errorf("%s: Ожидалось местоположение: %v, Получено: %v", format, want.Location, got.Location)
+			}
+		}
+		if _, _, err = r.Next(); !errors.Is(err, io.EOF) {
+			t.Errorf("%s: ожидали io.EOF, получили %v", format, err)
+		}
+	}
+}
+
+func TestReader_LineErrors(t *testing.T) {
+	csvData := "name,price\nреклама,10\nбез цены,abc\nещё,20\n"
+	r, err := NewReader(strings.NewReader(csvData), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []int
+	var failed []int
+	for {
+		_, line, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var lineErr *LineError
+		if errors.As(err, &lineErr) {
+			failed = append(failed, lineErr.Line)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) != 2 || lines[0] != 2 || lines[1] != 4 {
+		t.Errorf("Ожидали строки [2 4], получили %v", lines)
+	}
+	if len(failed) != 1 || failed[0] != 3 {
+		t.Errorf("Ожидали ошибку в строке 3, получили %v", failed)
+	}
+
+	ndjson := "{\"name\":\"a\",\"price\":1}\n\n{broken\n{\"name\":\"b\",\"price\":2}\n"
+	r, err = NewReader(strings.NewReader(ndjson), FormatNDJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, line, _ := r.Next()
+	_, badLine, err := r.Next()
+	var lineErr *LineError
+	if line != 1 || badLine != 3 || !errors.As(err, &lineErr) {
+		t.Errorf("Ожидали объявление в строке 1 и ошибку в строке 3, получили %d, %d, %v", line, badLine, err)
+	}
+
+	if _, err = NewReader(strings.NewReader("title\nx\n"), FormatCSV); err == nil {
+		t.Error("Ожидали ошибку заголовка без колонок name и price")
+	}
+}
@@ -1,15 +1,198 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"regexp"
+	"strconv"
+	"time"
+)
 
 type Ads struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	Creation    time.Time `json:"creation"`
+	ID          string    `json:"id" bson:"_id,omitempty"`
+	Name        string    `json:"name" bson:"name"`
+	Description string    `json:"description" bson:"description"`
+	Price       float64   `json:"price" bson:"price"`
+	Creation    time.Time `json:"creation" bson:"creation"`
+	Currency    string    `json:"currency,omitempty" bson:"currency,omitempty"`
+	Status      string    `json:"status,omitempty" bson:"status,omitempty" enums:"active,sold,archived"`
+	Owner       string    `json:"owner,omitempty" bson:"owner,omitempty" readonly:"true"`
+	Location    *Location `json:"location,omitempty" bson:"location,omitempty"`
+	// ExpiresAt Время, после которого объявление удаляется TTL индексом, nil для бессрочных объявлений
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+	// DistanceKm Расстояние до точки поиска, заполняется только при поиске по местоположению
+	DistanceKm *float64 `json:"distanceKm,omitempty" bson:"-"`
+}
+
+// Значения по умолчанию для новых объявлений
+const (
+	DefaultCurrency = "RUB"
+	StatusActive    = "active"
+)
+
+// Остальные допустимые статусы объявлений
+const (
+	StatusSold     = "sold"
+	StatusArchived = "archived"
+)
+
+// ValidStatus Проверяет, что статус объявления входит в список допустимых
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusActive, StatusSold, StatusArchived:
+		return true
+	}
+	return false
+}
+
+// currencyPattern Код валюты ISO 4217
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Validate Проверяет наличие обязательных полей объявления, корректность местоположения, валюты и статуса
+// и срок действия: объявление не может истечь в прошлом или позже, чем через maxLifetime (0 - без ограничения)
+func (a Ads) Validate(maxLifetime time.Duration) error {
+	if a.Name == "" || a.Price == 0 {
+		return errors.New("Обязательные поля name или price объявления отсутствуют")
+	}
+	if a.Location != nil && !a.Location.Valid() {
+		return errors.New("Некорректные координаты местоположения объявления")
+	}
+	if a.Currency != "" && !currencyPattern.MatchString(a.Currency) {
+		return errors.New("Код валюты currency должен состоять из трёх заглавных латинских букв")
+	}
+	if a.Status != "" && !ValidStatus(a.Status) {
+		return fmt.Errorf("Статус status должен быть одним из: %s, %s, %s", StatusActive, StatusSold, StatusArchived)
+	}
+	if a.ExpiresAt != nil {
+		now := time.Now()
+		if !a.ExpiresAt.After(now) {
+			return errors.New("Время окончания объявления expiresAt должно быть в будущем")
+		}
+		if maxLifetime > 0 && a.ExpiresAt.After(now.Add(maxLifetime)) {
+			return fmt.Errorf("Время окончания объявления expiresAt должно быть не позже, чем через %s", maxLifetime)
+		}
+	}
+	return nil
+}
+
+// RoundPrice Округляет цену до двух знаков после запятой
+func RoundPrice(price float64) (float64, error) {
+	return strconv.ParseFloat(fmt.Sprintf("%.2f", price), 64)
+}
+
+// Location Местоположение объявления
+type Location struct {
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	City    string  `json:"city,omitempty"`
+	Address string  `json:"address,omitempty"`
+}
+
+// geoPoint Представление местоположения в MongoDB: точка GeoJSON для индекса 2dsphere
+type geoPoint struct {
+	Type        string    `bson:"type"`
+	Coordinates []float64 `bson:"coordinates"`
+	City        string    `bson:"city,omitempty"`
+	Address     string    `bson:"address,omitempty"`
+}
+
+// MarshalBSON Сохраняет местоположение как точку GeoJSON, долгота идёт первой
+func (l Location) MarshalBSON() ([]byte, error) {
+	return bson.Marshal(geoPoint{
+		Type:        "Point",
+		Coordinates: []float64{l.Lon, l.Lat},
+		City:        l.City,
+		Address:     l.Address,
+	})
+}
+
+// UnmarshalBSON Читает местоположение из точки GeoJSON
+func (l *Location) UnmarshalBSON(data []byte) error {
+	var p geoPoint
+	if err := bson.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	if len(p.Coordinates) == 2 {
+		l.Lon, l.Lat = p.Coordinates[0], p.Coordinates[1]
+	}
+	l.City, l.Address = p.City, p.Address
+	return nil
+}
+
+// Valid Проверяет диапазоны широты и долготы
+func (l Location) Valid() bool {
+	return l.Lat >= -90 && l.Lat <= 90 && l.Lon >= -180 && l.Lon <= 180
 }
 
 type Response struct {
 	ID string `json:"id"`
 }
+
+// SearchResult Объявление, найденное полнотекстовым поиском, и его релевантность
+type SearchResult struct {
+	Ads   `bson:",inline"`
+	Score float64 `json:"score" bson:"score"`
+}
+
+// ListFilter Параметры выборки списка объявлений
+type ListFilter struct {
+	SortField string
+	SortOrder string
+	// Near Поиск объявлений рядом с точкой, nil если не задан
+	Near *GeoFilter
+}
+
+// GeoFilter Поиск в радиусе от точки
+type GeoFilter struct {
+	Lat float64
+	Lon float64
+	// RadiusKm Радиус поиска в километрах, 0 означает без ограничения
+	RadiusKm float64
+}
+
+// IndexState Состояние индекса коллекции относительно реестра индексов
+type IndexState struct {
+	Collection string `json:"collection"`
+	Name       string `json:"name"`
+	// State ok, missing, changed или unmanaged
+	State  string `json:"state"`
+	Spec   string `json:"spec,omitempty"`
+	Actual string `json:"actual,omitempty"`
+}
+
+// BatchItemResult Результат обработки одного объявления из пакета
+type BatchItemResult struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// BatchResponse Ответ на пакетное создание объявлений
+type BatchResponse struct {
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Items   []BatchItemResult `json:"items"`
+}
+
+// BatchGetResponse Ответ на пакетное получение объявлений
+type BatchGetResponse struct {
+	Items   []Ads    `json:"items"`
+	Missing []string `json:"missing"`
+}
+
+// IdempotencyRecord Сохранённый результат запроса с заголовком Idempotency-Key
+type IdempotencyRecord struct {
+	Key         string    `bson:"_id"`
+	RequestHash string    `bson:"requestHash"`
+	Status      int       `bson:"status"`
+	ContentType string    `bson:"contentType"`
+	Body        []byte    `bson:"body"`
+	CreatedAt   time.Time `bson:"createdAt"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
+}
+
+// Completed Сообщает, сохранён ли уже ответ на запрос
+func (r IdempotencyRecord) Completed() bool {
+	return r.Status != 0
+}
@@ -0,0 +1,206 @@
+package accesslog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"zatrasz75/Ads_service/pkg/httpx"
+	"zatrasz75/Ads_service/pkg/requestid"
+)
+
+// Форматы записей журнала доступа
+const (
+	// FormatCommon Common Log Format
+	FormatCommon = "common"
+	// FormatCombined Combined Log Format: Common с заголовками Referer и User-Agent
+	FormatCombined = "combined"
+	// FormatJSON Запись в формате JSON со всеми полями Entry
+	FormatJSON = "json"
+)
+
+// clfTime Формат времени в Common Log Format
+const clfTime = "02/Jan/2006:15:04:05 -0700"
+
+// Entry Запись журнала доступа
+type Entry struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id,omitempty"`
+	ClientIP   string    `json:"client_ip"`
+	User       string    `json:"user,omitempty"`
+	Method     string    `json:"method"`
+	Route      string    `json:"route"`
+	URI        string    `json:"uri"`
+	Proto      string    `json:"proto"`
+	Status     int       `json:"status"`
+	Bytes      int64     `json:"bytes"`
+	DurationMs float64   `json:"duration_ms"`
+	Referer    string    `json:"referer,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+}
+
+// Logger Журнал доступа: по строке на каждый обработанный запрос
+type Logger struct {
+	mu  sync.Mutex
+	out io.Writer
+
+	format     string
+	sampleRate float64
+	exclude    []string
+	trusted    []*net.IPNet
+
+	random func() float64
+	now    func() time.Time
+}
+
+// New Создаёт журнал доступа, по умолчанию Combined Log Format в stdout без выборки
+func New(opts ...Option) (*Logger, error) {
+	a := &Logger{
+		out:        os.Stdout,
+		format:     FormatCombined,
+		sampleRate: 1,
+		random:     rand.Float64,
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	switch a.format {
+	case FormatCommon, FormatCombined, FormatJSON:
+	default:
+		return nil, fmt.Errorf("неизвестный формат журнала доступа %q, допустимые значения: common, combined, json", a.format)
+	}
+	return a, nil
+}
+
+// Middleware Записывает запрос после его обработки. Шаблон маршрута известен только после сопоставления,
+// поэтому подключается через Router.Use. Ответы с кодом 5xx записываются независимо от выборки.
+func (a *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.excluded(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := a.now()
+		user := &userHolder{}
+		rec := httpx.NewRecorder(w)
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+
+		if rec.Status < http.StatusInternalServerError && a.sampleRate < 1 && a.random() >= a.sampleRate {
+			return
+		}
+
+		e := Entry{
+			Time:       start,
+			RequestID:  requestid.FromContext(r.Context()),
+			ClientIP:   httpx.ClientIP(r, a.trusted),
+			User:       user.get(),
+			Method:     r.Method,
+			Route:      httpx.RouteTemplate(r),
+			URI:        r.URL.RequestURI(),
+			Proto:      r.Proto,
+			Status:     rec.Status,
+			Bytes:      rec.Bytes,
+			DurationMs: float64(a.now().Sub(start).Microseconds()) / 1000,
+			Referer:    r.Referer(),
+			UserAgent:  r.UserAgent(),
+		}
+		if e.User == "" {
+			e.User, _, _ = r.BasicAuth()
+		}
+		a.write(e)
+	})
+}
+
+// write Записывает строку журнала, строки параллельных запросов не перемешиваются
+func (a *Logger) write(e Entry) {
+	var line []byte
+	if a.format == FormatJSON {
+		line, _ = json.Marshal(e)
+		line = append(line, '\n')
+	} else {
+		line = []byte(a.formatCLF(e))
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, _ = a.out.Write(line)
+}
+
+// formatCLF Форматирует запись в Common или Combined Log Format.
+// В конце строки добавляются шаблон маршрута, длительность в секундах и идентификатор запроса.
+func (a *Logger) formatCLF(e Entry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s - %s [%s] %s %d %s",
+		dash(e.ClientIP), dash(e.User), e.Time.Format(clfTime),
+		strconv.Quote(e.Method+" "+e.URI+" "+e.Proto), e.Status, dash(bytesField(e.Bytes)))
+	if a.format == FormatCombined {
+		fmt.Fprintf(&b, " %s %s", strconv.Quote(e.Referer), strconv.Quote(e.UserAgent))
+	}
+	fmt.Fprintf(&b, " %s %.3f %s\n", strconv.Quote(e.Route), e.DurationMs/1000, dash(e.RequestID))
+	return b.String()
+}
+
+// excluded Сообщает, исключён ли путь запроса из журнала: точное совпадение или префикс для шаблона с * в конце
+func (a *Logger) excluded(r *http.Request) bool {
+	for _, p := range a.exclude {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return true
+			}
+		} else if r.URL.Path == p {
+			return true
+		}
+	}
+	return false
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func bytesField(n int64) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// userKey Ключ пользователя запроса в контексте
+type userKey struct{}
+
+// userHolder Пользователь, которого обработчик или проверка доступа сообщают журналу доступа
+type userHolder struct {
+	mu sync.Mutex
+	id string
+}
+
+func (u *userHolder) get() string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.id
+}
+
+// SetUser Сообщает журналу доступа пользователя или ключ API запроса, вызывается при проверке доступа.
+// Вне запроса с журналом доступа ничего не делает.
+func SetUser(ctx context.Context, id string) {
+	if u, ok := ctx.Value(userKey{}).(*userHolder); ok {
+		u.mu.Lock()
+		u.id = id
+		u.mu.Unlock()
+	}
+}
@@ -0,0 +1,88 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"zatrasz75/Ads_service/pkg/httpx"
+)
+
+func newRouter(t *testing.T, opts ...Option) (*mux.Router, *Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	a, err := New(append([]Option{Output(&buf)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	calls := 0
+	a.now = func() time.Time {
+		calls++
+		return start.Add(time.Duration(calls-1) * 15 * time.Millisecond)
+	}
+
+	r := mux.NewRouter()
+	r.Use(a.Middleware)
+	r.HandleFunc("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		SetUser(r.Context(), "key-1")
+		_, _ = w.Write([]byte("hello"))
+	})
+	r.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+	return r, a, &buf
+}
+
+func TestMiddleware_Combined(t *testing.T) {
+	proxies, _ := httpx.ParseProxies([]string{"10.0.0.0/8"})
+	r, _, buf := newRouter(t, TrustedProxies(proxies), Exclude("/healthz"))
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/42?fields=description", nil)
+	req.RemoteAddr = "10.0.0.5:51000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.9")
+	req.Header.Set("User-Agent", `curl/8 "test"`)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	want := `203.0.113.7 - key-1 [01/May/2024:10:00:00 +0000] "GET /posts/42?fields=description HTTP/1.1" 200 5 "" "curl/8 \"test\"" "/posts/{id}" 0.015 -` + "\n"
+	if buf.String() != want {
+		t.Errorf("запись\n%q\nожидалась\n%q", buf.String(), want)
+	}
+}
+
+func TestMiddleware_JSONAndSampling(t *testing.T) {
+	r, a, buf := newRouter(t, Format(FormatJSON), SampleRate(0.5))
+
+	// Первый запрос отброшен выборкой, второй записан, ошибка записывается всегда
+	rnd := []float64{0.7, 0.2, 0.9}
+	a.random = func() float64 { v := rnd[0]; rnd = rnd[1:]; return v }
+	for _, path := range []string{"/posts/1", "/posts/2", "/fail"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "198.51.100.1:1234"
+		req.Header.Set("X-Forwarded-For", "1.2.3.4")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("записей %d, ожидалось 2: %s", len(lines), buf)
+	}
+	var e Entry
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatal(err)
+	}
+	// Прокси не доверенный, заголовок X-Forwarded-For не учитывается
+	if e.URI != "/posts/2" || e.ClientIP != "198.51.100.1" || e.Route != "/posts/{id}" || e.Bytes != 5 || e.DurationMs != 15 {
+		t.Errorf("запись %+v", e)
+	}
+	if !regexp.MustCompile(`"status":500`).MatchString(lines[1]) {
+		t.Errorf("ошибка не записана: %s", lines[1])
+	}
+}
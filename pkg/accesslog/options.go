@@ -0,0 +1,44 @@
+package accesslog
+
+import (
+	"io"
+	"net"
+)
+
+// Option -.
+type Option func(*Logger)
+
+// Format Формат записей: common, combined или json
+func Format(format string) Option {
+	return func(a *Logger) {
+		a.format = format
+	}
+}
+
+// Output Получатель записей
+func Output(w io.Writer) Option {
+	return func(a *Logger) {
+		a.out = w
+	}
+}
+
+// SampleRate Доля записываемых запросов от 0 до 1, ответы 5xx записываются всегда
+func SampleRate(rate float64) Option {
+	return func(a *Logger) {
+		a.sampleRate = rate
+	}
+}
+
+// Exclude Пути, запросы к которым не записываются, например /healthz. Шаблон с * в конце задаёт префикс: /debug/*
+func Exclude(paths ...string) Option {
+	return func(a *Logger) {
+		a.exclude = append(a.exclude, paths...)
+	}
+}
+
+// TrustedProxies Прокси, адресам из X-Forwarded-For и X-Real-IP от которых можно доверять
+func TrustedProxies(nets []*net.IPNet) Option {
+	return func(a *Logger) {
+		a.trusted = nets
+	}
+}
@@ -0,0 +1,336 @@
+package compress
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Поддерживаемые кодировки
+const (
+	Zstd    = "zstd"
+	Gzip    = "gzip"
+	Deflate = "deflate"
+)
+
+const (
+	_defaultMinSize = 1024
+	_defaultLevel   = 5
+	// _zstdWindowSize Окно zstd меньше стандартного, чтобы кодировщики в пуле не занимали по 8 МБ
+	_zstdWindowSize = 1 << 20
+)
+
+var (
+	_defaultEncodings    = []string{Zstd, Gzip, Deflate}
+	_defaultContentTypes = []string{
+		"application/json",
+		"application/x-ndjson",
+		"application/problem+json",
+		"application/yaml",
+		"text/csv",
+		"text/html",
+		"text/plain",
+	}
+)
+
+// encoder Общий интерфейс кодировщиков, позволяющий переиспользовать их через пул
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Compressor Сжимает ответы кодировкой, выбранной по заголовку Accept-Encoding
+type Compressor struct {
+	minSize      int
+	level        int
+	encodings    []string
+	contentTypes []string
+	pools        map[string]*sync.Pool
+}
+
+// New Создаёт middleware сжатия. Кодировки перечисляются в порядке предпочтения сервера,
+// он учитывается, когда клиент принимает несколько кодировок с одинаковым весом.
+func New(opts ...Option) (*Compressor, error) {
+	c := &Compressor{
+		minSize:      _defaultMinSize,
+		level:        _defaultLevel,
+		encodings:    _defaultEncodings,
+		contentTypes: _defaultContentTypes,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.level < 1 || c.level > 9 {
+		return nil, fmt.Errorf("уровень сжатия должен быть от 1 до 9, получено %d", c.level)
+	}
+	c.pools = make(map[string]*sync.Pool, len(c.encodings))
+	for _, name := range c.encodings {
+		newEncoder, err := c.encoderFunc(name)
+		if err != nil {
+			return nil, err
+		}
+		// Первый кодировщик создаётся сразу, чтобы ошибки параметров были видны при запуске
+		enc, err := newEncoder()
+		if err != nil {
+			return nil, fmt.Errorf("не удалось создать кодировщик %s: %w", name, err)
+		}
+		pool := &sync.Pool{New: func() any {
+			enc, _ := newEncoder()
+			return enc
+		}}
+		pool.Put(enc)
+		c.pools[name] = pool
+	}
+	return c, nil
+}
+
+// encoderFunc Возвращает конструктор кодировщика с уровнем сжатия c.level
+func (c *Compressor) encoderFunc(name string) (func() (encoder, error), error) {
+	switch name {
+	case Gzip:
+		return func() (encoder, error) { return gzip.NewWriterLevel(io.Discard, c.level) }, nil
+	case Deflate:
+		return func() (encoder, error) { return flate.NewWriter(io.Discard, c.level) }, nil
+	case Zstd:
+		return func() (encoder, error) {
+			return zstd.NewWriter(nil,
+				zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.level)),
+				zstd.WithEncoderConcurrency(1),
+				zstd.WithWindowSize(_zstdWindowSize),
+			)
+		}, nil
+	default:
+		return nil, fmt.Errorf("неизвестная кодировка %q, допустимые значения: zstd, gzip, deflate", name)
+	}
+}
+
+// Middleware Сжимает ответы, если клиент принимает одну из кодировок, тип содержимого входит в список
+// и тело не меньше минимального размера. Размер определяется по началу ответа: пока не набрано minSize байт,
+// ответ копится в буфере. Принудительный сброс ответа (http.Flusher) отключает проверку размера,
+// поэтому потоковые выгрузки сжимаются с самого начала.
+func (c *Compressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := c.negotiate(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &responseWriter{ResponseWriter: w, c: c, encoding: encoding}
+		next.ServeHTTP(cw, r)
+		// При панике ответ не дописывается: соединение всё равно будет разорвано
+		cw.close()
+	})
+}
+
+// negotiate Выбирает кодировку с наибольшим весом q из Accept-Encoding, при равном весе - по порядку сервера.
+// Пустая строка означает, что ответ отправляется без сжатия.
+func (c *Compressor) negotiate(header string) string {
+	if header == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, name := range c.encodings {
+		q, ok := weights[name]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressible Проверяет, входит ли тип содержимого в список сжимаемых. Шаблон text/* задаёт все подтипы.
+func (c *Compressor) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range c.contentTypes {
+		if prefix, ok := strings.CutSuffix(t, "*"); ok {
+			if strings.HasPrefix(mediaType, prefix) {
+				return true
+			}
+		} else if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Compressor) getEncoder(name string, w io.Writer) encoder {
+	enc := c.pools[name].Get().(encoder)
+	enc.Reset(w)
+	return enc
+}
+
+func (c *Compressor) putEncoder(name string, enc encoder) {
+	// Ссылка на ResponseWriter не должна жить в пуле
+	enc.Reset(io.Discard)
+	c.pools[name].Put(enc)
+}
+
+// responseWriter Откладывает отправку заголовков, пока не станет ясно, сжимать ли ответ
+type responseWriter struct {
+	http.ResponseWriter
+	c        *Compressor
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     encoder
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	// Информационные ответы отправляются сразу и не влияют на решение
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.c.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush Отправляет накопленные данные клиенту, решение о сжатии принимается без учёта размера
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap Возвращает исходный ResponseWriter для http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide Отправляет заголовки и накопленный буфер, выбрав между сжатием и передачей как есть
+func (w *responseWriter) decide(flushed bool) error {
+	w.decided = true
+	if w.shouldCompress(flushed) {
+		h := w.Header()
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		// Сжатое тело отличается от исходного побайтно, сильный ETag стал бы неверным
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.enc = w.c.getEncoder(w.encoding, w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *responseWriter) shouldCompress(flushed bool) bool {
+	switch w.status {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	if !flushed && len(w.buf) < w.c.minSize {
+		return false
+	}
+	if cl, err := strconv.Atoi(h.Get("Content-Length")); err == nil && cl < w.c.minSize {
+		return false
+	}
+	// Без Content-Type net/http определил бы тип по началу тела, после сжатия это уже невозможно
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	return w.c.compressible(h.Get("Content-Type"))
+}
+
+// close Завершает ответ: отправляет короткий ответ без сжатия или дописывает сжатый поток
+func (w *responseWriter) close() {
+	if !w.decided {
+		// Обработчик ничего не отправил, код 200 подставит net/http
+		if w.status == 0 {
+			return
+		}
+		if err := w.decide(false); err != nil {
+			return
+		}
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		w.c.putEncoder(w.encoding, w.enc)
+		w.enc = nil
+	}
+}
@@ -0,0 +1,187 @@
+package compress
+
+import (
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompressor_Negotiate(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "gzip, deflate, br", want: Gzip},
+		{header: "gzip, zstd", want: Zstd},
+		{header: "zstd;q=0.5, gzip", want: Gzip},
+		{header: "gzip;q=0, deflate", want: Deflate},
+		{header: "*", want: Zstd},
+		{header: "*;q=0.1, gzip;q=0.5", want: Gzip},
+		{header: "identity", want: ""},
+		{header: "br", want: ""},
+	}
+	for _, tt := range tests {
+		if got := c.negotiate(tt.header); got != tt.want {
+			t.Errorf("negotiate(%q) = %q, ожидалось %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCompressor_Middleware(t *testing.T) {
+	c, err := New(MinSize(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := strings.Repeat(`{"name":"объявление"},`, 50)
+
+	tests := []struct {
+		name         string
+		accept       string
+		contentType  string
+		body         string
+		wantEncoding string
+	}{
+		{name: "gzip", accept: "gzip", contentType: "application/json", body: long, wantEncoding: Gzip},
+		{name: "zstd", accept: "zstd, gzip", contentType: "application/json; charset=utf-8", body: long, wantEncoding: Zstd},
+		{name: "короткий ответ", accept: "gzip", contentType: "application/json", body: `[]`},
+		{name: "тип не из списка", accept: "gzip", contentType: "image/png", body: long},
+		{name: "клиент не принимает сжатие", contentType: "application/json", body: long},
+		{name: "тип определяется по телу", accept: "gzip", body: strings.Repeat("текст ", 50), wantEncoding: Gzip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				// Тело пишется частями, чтобы решение принималось не по первой записи
+				for _, chunk := range strings.SplitAfter(tt.body, ",") {
+					_, _ = io.WriteString(w, chunk)
+				}
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/posts/list", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding %q, ожидался %q", got, tt.wantEncoding)
+			}
+			if got := rr.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary %q", got)
+			}
+			if got := decode(t, tt.wantEncoding, rr.Body); got != tt.body {
+				t.Errorf("тело после распаковки %q, ожидалось %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestCompressor_Flush(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		_, _ = io.WriteString(w, "name,price\n")
+		w.(http.Flusher).Flush()
+		_, _ = io.WriteString(w, "стол,100\n")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/admin/export", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if !rr.Flushed {
+		t.Error("сброс не дошёл до клиента")
+	}
+	if got := rr.Header().Get("Content-Encoding"); got != Gzip {
+		t.Fatalf("потоковый ответ не сжат, Content-Encoding %q", got)
+	}
+	if got := decode(t, Gzip, rr.Body); got != "name,price\nстол,100\n" {
+		t.Errorf("тело %q", got)
+	}
+}
+
+func TestCompressor_Skip(t *testing.T) {
+	c, err := New(MinSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		handler http.HandlerFunc
+	}{
+		{name: "уже сжато", method: http.MethodGet, handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", "br")
+			_, _ = io.WriteString(w, "сжатые данные")
+		}},
+		{name: "без тела", method: http.MethodGet, handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}},
+		{name: "HEAD", method: http.MethodHead, handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rr := httptest.NewRecorder()
+			c.Middleware(tt.handler).ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Content-Encoding"); got == Gzip {
+				t.Errorf("ответ не должен сжиматься")
+			}
+		})
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	if _, err := New(Level(10)); err == nil {
+		t.Error("ожидалась ошибка уровня сжатия")
+	}
+	if _, err := New(Encodings("br")); err == nil {
+		t.Error("ожидалась ошибка неизвестной кодировки")
+	}
+}
+
+func decode(t *testing.T, encoding string, r io.Reader) string {
+	t.Helper()
+	switch encoding {
+	case Gzip:
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	case Zstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,32 @@
+package compress
+
+// Option -.
+type Option func(*Compressor)
+
+// MinSize Минимальный размер тела в байтах, начиная с которого ответ сжимается
+func MinSize(n int) Option {
+	return func(c *Compressor) {
+		c.minSize = n
+	}
+}
+
+// Level Уровень сжатия от 1 (быстрее) до 9 (сильнее), для zstd переводится в ближайший уровень кодировщика
+func Level(level int) Option {
+	return func(c *Compressor) {
+		c.level = level
+	}
+}
+
+// Encodings Кодировки в порядке предпочтения сервера: zstd, gzip, deflate
+func Encodings(names ...string) Option {
+	return func(c *Compressor) {
+		c.encodings = names
+	}
+}
+
+// ContentTypes Сжимаемые типы содержимого, например application/json или text/*
+func ContentTypes(types ...string) Option {
+	return func(c *Compressor) {
+		c.contentTypes = types
+	}
+}
@@ -0,0 +1,187 @@
+package cors
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Handler Обрабатывает запросы CORS перед маршрутизатором gorilla/mux. Предварительные запросы OPTIONS
+// обрабатываются для любого зарегистрированного маршрута, даже если OPTIONS не указан в его методах:
+// разрешённые методы определяются по маршрутам. Правила можно заменить во время работы через Update.
+type Handler struct {
+	router *mux.Router
+	policy atomic.Pointer[policy]
+}
+
+// policy Правила CORS
+type policy struct {
+	origins     []string
+	methods     []string
+	headers     []string
+	exposed     []string
+	credentials bool
+	maxAge      time.Duration
+}
+
+// New Создаёт обработчик CORS для router. Без разрешённых источников запросы передаются router без изменений.
+func New(router *mux.Router, opts ...Option) *Handler {
+	h := &Handler{router: router}
+	h.Update(opts...)
+	return h
+}
+
+// Update Заменяет правила, например после перезагрузки конфигурации
+func (h *Handler) Update(opts ...Option) {
+	p := &policy{methods: []string{http.MethodGet, http.MethodPost}}
+	for _, opt := range opts {
+		opt(p)
+	}
+	h.policy.Store(p)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := h.policy.Load()
+	if len(p.origins) == 0 {
+		h.router.ServeHTTP(w, r)
+		return
+	}
+
+	// Ответ зависит от источника, кэши не должны отдавать его другим источникам
+	w.Header().Add("Vary", "Origin")
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || !p.allowOrigin(origin) {
+		h.router.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		h.preflight(w, r, p, origin)
+		return
+	}
+
+	p.setOrigin(w.Header(), origin)
+	if len(p.exposed) > 0 {
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(p.exposed, ", "))
+	}
+	h.router.ServeHTTP(w, r)
+}
+
+// preflight Отвечает на предварительный запрос: 204 с разрешёнными методами и заголовками маршрута,
+// 403 если метод или заголовки не разрешены. Запрос к несуществующему маршруту передаётся router.
+func (h *Handler) preflight(w http.ResponseWriter, r *http.Request, p *policy, origin string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	methods := h.routeMethods(r, p.methods)
+	if len(methods) == 0 {
+		h.router.ServeHTTP(w, r)
+		return
+	}
+
+	requested := r.Header.Get("Access-Control-Request-Method")
+	if !contains(methods, requested) {
+		http.Error(w, "Метод не разрешён для запросов из другого источника", http.StatusForbidden)
+		return
+	}
+	headers := requestedHeaders(r)
+	for _, header := range headers {
+		if !contains(p.headers, header) {
+			http.Error(w, "Заголовок "+header+" не разрешён для запросов из другого источника", http.StatusForbidden)
+			return
+		}
+	}
+
+	hdr := w.Header()
+	p.setOrigin(hdr, origin)
+	hdr.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(headers) > 0 {
+		hdr.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if p.maxAge > 0 {
+		hdr.Set("Access-Control-Max-Age", strconv.Itoa(int(p.maxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// routeMethods Возвращает разрешённые методы, для которых у пути запроса есть маршрут
+func (h *Handler) routeMethods(r *http.Request, allowed []string) []string {
+	var methods []string
+	for _, m := range allowed {
+		probe := r.Clone(r.Context())
+		probe.Method = m
+		var match mux.RouteMatch
+		if h.router.Match(probe, &match) && match.MatchErr == nil {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// setOrigin Разрешает ответ источнику origin
+func (p *policy) setOrigin(h http.Header, origin string) {
+	if contains(p.origins, "*") && !p.credentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowOrigin Проверяет источник: * разрешает любой, https://*.example.com разрешает поддомены example.com
+func (p *policy) allowOrigin(origin string) bool {
+	for _, allowed := range p.origins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		if matchWildcard(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWildcard Сравнивает источник с шаблоном scheme://*.domain[:port]
+func matchWildcard(pattern, origin string) bool {
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil || !strings.EqualFold(u.Scheme, scheme) {
+		return false
+	}
+	got := strings.ToLower(u.Host)
+	suffix := "." + strings.ToLower(host)
+	return strings.HasSuffix(got, suffix) && len(got) > len(suffix)
+}
+
+// requestedHeaders Разбирает Access-Control-Request-Headers
+func requestedHeaders(r *http.Request) []string {
+	var headers []string
+	for _, v := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, h := range strings.Split(v, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				headers = append(headers, http.CanonicalHeaderKey(h))
+			}
+		}
+	}
+	return headers
+}
+
+// contains Ищет значение без учёта регистра, как сравниваются методы и заголовки в CORS
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
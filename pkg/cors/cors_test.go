@@ -0,0 +1,98 @@
+package cors
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newHandler(opts ...Option) *Handler {
+	r := mux.NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	r.HandleFunc("/posts", ok).Methods(http.MethodGet)
+	r.HandleFunc("/posts", ok).Methods(http.MethodPost)
+	r.HandleFunc("/posts/list", ok).Methods(http.MethodGet)
+	return New(r, opts...)
+}
+
+func TestHandler_Preflight(t *testing.T) {
+	h := newHandler(
+		AllowedOrigins("https://app.example.com", "https://*.example.org"),
+		AllowedHeaders("Content-Type", "Idempotency-Key"),
+		MaxAge(10*time.Minute),
+	)
+
+	tests := []struct {
+		name        string
+		path        string
+		origin      string
+		method      string
+		headers     string
+		wantCode    int
+		wantMethods string
+	}{
+		{name: "маршрут с двумя методами", path: "/posts", origin: "https://app.example.com", method: "POST", headers: "content-type, idempotency-key", wantCode: 204, wantMethods: "GET, POST"},
+		{name: "поддомен", path: "/posts/list", origin: "https://shop.example.org", method: "GET", wantCode: 204, wantMethods: "GET"},
+		{name: "метод маршрута не разрешён", path: "/posts/list", origin: "https://app.example.com", method: "POST", wantCode: 403},
+		{name: "заголовок не разрешён", path: "/posts", origin: "https://app.example.com", method: "POST", headers: "X-Secret", wantCode: 403},
+		{name: "домен без поддомена", path: "/posts", origin: "https://example.org", method: "GET", wantCode: 405},
+		{name: "неизвестный маршрут", path: "/admin", origin: "https://app.example.com", method: "GET", wantCode: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("код %d, ожидался %d", rr.Code, tt.wantCode)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Errorf("методы %q, ожидались %q", got, tt.wantMethods)
+			}
+			if tt.wantCode == 204 {
+				if rr.Header().Get("Access-Control-Allow-Origin") != tt.origin || rr.Header().Get("Access-Control-Max-Age") != "600" {
+					t.Errorf("заголовки %v", rr.Header())
+				}
+			}
+			if rr.Header().Get("Vary") == "" {
+				t.Error("нет заголовка Vary")
+			}
+		})
+	}
+}
+
+func TestHandler_ActualRequestAndUpdate(t *testing.T) {
+	h := newHandler(AllowedOrigins("*"), ExposedHeaders("X-Request-ID"))
+
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.Header.Set("Origin", "https://any.example")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "*" || rr.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" || rr.Header().Get("Vary") != "Origin" {
+		t.Errorf("заголовки %v", rr.Header())
+	}
+
+	// С cookie источник указывается явно
+	h.Update(AllowedOrigins("https://app.example.com"), AllowCredentials(true))
+	req.Header.Set("Origin", "https://app.example.com")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" || rr.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("заголовки после обновления %v", rr.Header())
+	}
+
+	req.Header.Set("Origin", "https://any.example")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" || rr.Code != http.StatusOK {
+		t.Errorf("чужой источник: код %d, заголовки %v", rr.Code, rr.Header())
+	}
+}
@@ -0,0 +1,48 @@
+package cors
+
+import "time"
+
+// Option -.
+type Option func(*policy)
+
+// AllowedOrigins Разрешённые источники: https://app.example.com, шаблон поддоменов https://*.example.com или * для любого
+func AllowedOrigins(origins ...string) Option {
+	return func(p *policy) {
+		p.origins = origins
+	}
+}
+
+// AllowedMethods Методы, разрешённые для запросов из другого источника, по умолчанию GET и POST
+func AllowedMethods(methods ...string) Option {
+	return func(p *policy) {
+		p.methods = methods
+	}
+}
+
+// AllowedHeaders Заголовки запроса, которые может отправлять браузер, кроме стандартных
+func AllowedHeaders(headers ...string) Option {
+	return func(p *policy) {
+		p.headers = headers
+	}
+}
+
+// ExposedHeaders Заголовки ответа, доступные скрипту, например X-Request-ID
+func ExposedHeaders(headers ...string) Option {
+	return func(p *policy) {
+		p.exposed = headers
+	}
+}
+
+// AllowCredentials Разрешает запросы с cookie и заголовком Authorization
+func AllowCredentials(allow bool) Option {
+	return func(p *policy) {
+		p.credentials = allow
+	}
+}
+
+// MaxAge Сколько браузер может кэшировать ответ на предварительный запрос
+func MaxAge(d time.Duration) Option {
+	return func(p *policy) {
+		p.maxAge = d
+	}
+}
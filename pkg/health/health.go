@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const _defaultTimeout = 2 * time.Second
+
+// Состояние проверки
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// ErrShuttingDown Сервис завершает работу и не принимает новые запросы
+var ErrShuttingDown = errors.New("сервис завершает работу")
+
+// CheckFunc Проверка зависимости, ошибка означает, что зависимость недоступна
+type CheckFunc func(ctx context.Context) error
+
+// Result Результат проверки одной зависимости
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report Результат проверки всех зависимостей
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Up Сообщает, что все зависимости доступны
+func (r Report) Up() bool {
+	return r.Status == StatusUp
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker Проверяет готовность сервиса к обработке запросов.
+// После Drain сервис считается неготовым, чтобы балансировщик перестал направлять на него запросы.
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+
+	draining atomic.Bool
+}
+
+// New Создаёт Checker без проверок
+func New(opts ...Option) *Checker {
+	c := &Checker{timeout: _defaultTimeout}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Add Регистрирует проверку зависимости под именем name
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Drain Переводит сервис в состояние завершения работы, после вызова проверка готовности не проходит
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Draining Сообщает, что сервис завершает работу
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Check Выполняет все проверки параллельно, каждая ограничена временем ожидания
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks)+1)}
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, ch := range checks {
+		wg.Add(1)
+		go func(i int, ch check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, ch.fn)
+		}(i, ch)
+	}
+	wg.Wait()
+
+	for i, ch := range checks {
+		report.Checks[ch.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	if c.Draining() {
+		report.Status = StatusDown
+		report.Checks["shutdown"] = Result{Status: StatusDown, Error: ErrShuttingDown.Error()}
+	}
+	return report
+}
+
+// run Выполняет проверку и измеряет её длительность
+func (c *Checker) run(ctx context.Context, fn CheckFunc) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := fn(ctx)
+	res := Result{Status: StatusUp, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	return res
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChecker_Check(t *testing.T) {
+	c := New(Timeout(50 * time.Millisecond))
+	c.Add("db", func(context.Context) error { return nil })
+
+	report := c.Check(context.Background())
+	if !report.Up() || report.Checks["db"].Status != StatusUp {
+		t.Fatalf("ожидалась готовность, получено %+v", report)
+	}
+
+	c.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	c.Add("broken", func(context.Context) error { return errors.New("нет соединения") })
+
+	report = c.Check(context.Background())
+	if report.Up() {
+		t.Fatal("ожидалась неготовность")
+	}
+	if r := report.Checks["slow"]; r.Status != StatusDown || r.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("slow = %+v, ожидалось превышение времени ожидания", r)
+	}
+	if r := report.Checks["broken"]; r.Error != "нет соединения" {
+		t.Errorf("broken = %+v", r)
+	}
+	if report.Checks["db"].Status != StatusUp {
+		t.Errorf("db = %+v, ожидалась доступность", report.Checks["db"])
+	}
+}
+
+func TestChecker_Drain(t *testing.T) {
+	c := New()
+	c.Add("db", func(context.Context) error { return nil })
+	c.Drain()
+
+	report := c.Check(context.Background())
+	if report.Up() || report.Checks["shutdown"].Status != StatusDown {
+		t.Errorf("после Drain ожидалась неготовность, получено %+v", report)
+	}
+}
@@ -0,0 +1,13 @@
+package health
+
+import "time"
+
+// Option -.
+type Option func(*Checker)
+
+// Timeout Время ожидания одной проверки
+func Timeout(timeout time.Duration) Option {
+	return func(c *Checker) {
+		c.timeout = timeout
+	}
+}
@@ -0,0 +1,80 @@
+package httpx
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ParseProxies Разбирает адреса доверенных прокси: отдельные IP или подсети в нотации CIDR
+func ParseProxies(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("некорректный адрес прокси %q", v)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная подсеть прокси %q", v)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// ClientIP Возвращает адрес клиента. Заголовки X-Forwarded-For и X-Real-IP учитываются, только если запрос
+// пришёл от доверенного прокси: X-Forwarded-For просматривается справа налево до первого недоверенного адреса,
+// иначе клиент мог бы подставить любой адрес.
+func ClientIP(r *http.Request, trusted []*net.IPNet) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !isTrusted(remote, trusted) {
+		return remote
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" {
+				continue
+			}
+			if i == 0 || !isTrusted(hop, trusted) {
+				return hop
+			}
+		}
+	}
+	if real := strings.TrimSpace(r.Header.Get("X-Real-IP")); real != "" {
+		return real
+	}
+	return remote
+}
+
+func isTrusted(addr string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
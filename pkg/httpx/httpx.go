@@ -0,0 +1,62 @@
+package httpx
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// Recorder Запоминает код ответа и количество записанных байт, сохраняя потоковую передачу
+type Recorder struct {
+	http.ResponseWriter
+	Status      int
+	Bytes       int64
+	wroteHeader bool
+}
+
+// NewRecorder Оборачивает w, код ответа по умолчанию 200
+func NewRecorder(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (r *Recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.Status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *Recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.Bytes += int64(n)
+	return n, err
+}
+
+// WroteHeader Сообщает, начата ли отправка ответа
+func (r *Recorder) WroteHeader() bool {
+	return r.wroteHeader
+}
+
+// Flush Сохраняет потоковую передачу ответа через обёртку
+func (r *Recorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		r.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Unwrap Возвращает исходный ResponseWriter для http.ResponseController
+func (r *Recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// RouteTemplate Возвращает шаблон маршрута gorilla/mux, например /admin/import/{id},
+// чтобы идентификаторы в пути не попадали в метрики и имена операций
+func RouteTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return "unmatched"
+}
@@ -0,0 +1,19 @@
+package logger
+
+import "context"
+
+// ctxKey Ключ журнала в контексте
+type ctxKey struct{}
+
+// NewContext Возвращает контекст с журналом l, например журналом запроса с его идентификатором
+func NewContext(ctx context.Context, l LoggersInterface) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext Возвращает журнал из ctx, если его нет, возвращается fallback
+func FromContext(ctx context.Context, fallback LoggersInterface) LoggersInterface {
+	if l, ok := ctx.Value(ctxKey{}).(LoggersInterface); ok {
+		return l
+	}
+	return fallback
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"fmt"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+)
+
+const (
+	_defaultMaxSize    = 100 // МБ
+	_defaultMaxBackups = 5
+)
+
+// NewFile Открывает файл журнала с ротацией при записи: при превышении размера файл переименовывается
+// в <имя>-<время>.<расширение>, старые копии удаляются по количеству и возрасту, при необходимости сжимаются gzip.
+// Каталог файла создаётся, если его нет.
+func NewFile(path string, opts ...FileOption) (io.WriteCloser, error) {
+	f := &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    _defaultMaxSize,
+		MaxBackups: _defaultMaxBackups,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	// lumberjack открывает файл при первой записи, пустая запись проверяет путь сразу
+	if _, err := f.Write(nil); err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл журнала %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// FileOption -.
+type FileOption func(*lumberjack.Logger)
+
+// MaxSize Размер файла в мегабайтах, после которого выполняется ротация
+func MaxSize(mb int) FileOption {
+	return func(f *lumberjack.Logger) {
+		f.MaxSize = mb
+	}
+}
+
+// MaxAge Сколько дней хранить старые файлы, 0 - не удалять по возрасту
+func MaxAge(days int) FileOption {
+	return func(f *lumberjack.Logger) {
+		f.MaxAge = days
+	}
+}
+
+// MaxBackups Сколько старых файлов хранить, 0 - не удалять по количеству
+func MaxBackups(n int) FileOption {
+	return func(f *lumberjack.Logger) {
+		f.MaxBackups = n
+	}
+}
+
+// Compress Сжимать старые файлы gzip
+func Compress(enabled bool) FileOption {
+	return func(f *lumberjack.Logger) {
+		f.Compress = enabled
+	}
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFile_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "app.log")
+
+	f, err := NewFile(path, MaxSize(1), MaxBackups(1), Compress(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Три ротации, должна остаться одна сжатая копия
+	line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+	for i := 0; i < 3*1024+1; i++ {
+		if _, err = f.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Сжатие и удаление старых копий выполняются в фоне
+	var backups []string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		backups = nil
+		entries, _ := os.ReadDir(filepath.Dir(path))
+		for _, e := range entries {
+			if e.Name() != "app.log" {
+				backups = append(backups, e.Name())
+			}
+		}
+		if len(backups) == 1 && strings.HasSuffix(backups[0], ".log.gz") {
+			return
+		}
+	}
+	t.Errorf("старые файлы журнала %v, ожидалась одна копия .log.gz", backups)
+}
+
+func TestNewFile_Unwritable(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "file")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFile(filepath.Join(blocker, "app.log")); err == nil {
+		t.Error("ожидалась ошибка для пути внутри файла")
+	}
+}
@@ -6,7 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
-	"sync"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,32 +18,69 @@ type LoggersInterface interface {
 	Fatal(message string, err error)
 	Debug(message string, args ...interface{})
 }
+
+// LevelSetter Журнал, уровень которого можно менять во время работы
+type LevelSetter interface {
+	SetLevel(level string) error
+}
+
 type MyLogger struct {
-	logger        *log.Logger
-	logFile       *os.File
-	fileSizeMutex sync.Mutex
+	logger *log.Logger
+	level  atomic.Int32
+}
+
+// Уровни журнала, сообщения ниже установленного уровня не записываются
+const (
+	LevelDebug int32 = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// ParseLevel Возвращает уровень журнала по названию: debug, info, warn или error
+func ParseLevel(name string) (int32, error) {
+	switch strings.ToLower(name) {
+	case "debug", "":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return 0, fmt.Errorf("неизвестный уровень журнала %q, допустимые значения: debug, info, warn, error", name)
+	}
+}
+
+// SetLevel Устанавливает уровень журнала, может вызываться во время работы
+func (l *MyLogger) SetLevel(name string) error {
+	level, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	l.level.Store(level)
+	return nil
+}
+
+func (l *MyLogger) enabled(level int32) bool {
+	return level >= l.level.Load()
 }
 
 func NewLogger() LoggersInterface {
-	filePath := "app.log"
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	file, err := NewFile("app.log", MaxSize(maxLogFileSize))
 	if err != nil {
 		log.Fatal("Ошибка открытия файла журнала:", err)
 	}
 	logger := log.New(file, "", log.Ldate|log.Ltime|log.Lmicroseconds)
 
-	myLogger := &MyLogger{
-		logger:  logger,
-		logFile: file,
+	return &MyLogger{
+		logger: logger,
 	}
-
-	// Запускаем горутину для проверки размера файла и его обработки
-	go myLogger.checkAndRotateLogFile()
-
-	return myLogger
 }
 
-const maxLogFileSize = 5 * 1024 * 1024 // 10 MB
+// maxLogFileSize Размер файла журнала в мегабайтах, после которого выполняется ротация
+const maxLogFileSize = 5
 
 func logWithCallerInfo(file string, line int, level string, message string, l *log.Logger, args ...interface{}) {
 	caller := fmt.Sprintf("%s:%d", filepath.Base(file), line)
@@ -54,6 +92,9 @@ func logWithCallerInfo(file string, line int, level string, message string, l *l
 // Error записывает сообщение об ошибке в лог вместе с контекстом вызова функции.
 // Параметр err содержит ошибку, связанную с данным сообщением.
 func (l *MyLogger) Error(message string, err error) {
+	if !l.enabled(LevelError) {
+		return
+	}
 	_, file, line, _ := runtime.Caller(1)
 	if l.logger != nil {
 		logWithCallerInfo(file, line, "ERROR", "%s: %v", l.logger, message, err)
@@ -65,6 +106,9 @@ func (l *MyLogger) Error(message string, err error) {
 // Info записывает информационное сообщение в лог вместе с контекстом вызова функции.
 // Параметры args содержат дополнительные данные для сообщения.
 func (l *MyLogger) Info(message string, args ...interface{}) {
+	if !l.enabled(LevelInfo) {
+		return
+	}
 	_, file, line, _ := runtime.Caller(1)
 	if l.logger != nil {
 		logWithCallerInfo(file, line, "INFO", message, l.logger, args...)
@@ -74,6 +118,9 @@ func (l *MyLogger) Info(message string, args ...interface{}) {
 }
 
 func (l *MyLogger) Warn(message string, args ...interface{}) {
+	if !l.enabled(LevelWarn) {
+		return
+	}
 	_, file, line, _ := runtime.Caller(1)
 	if l.logger != nil {
 		logWithCallerInfo(file, line, "WARN", message, l.logger, args...)
@@ -94,6 +141,9 @@ func (l *MyLogger) Fatal(message string, err error) {
 // Debug записывает информационное сообщение в лог вместе с контекстом вызова функции.
 // Параметры args содержат дополнительные данные для сообщения.
 func (l *MyLogger) Debug(message string, args ...interface{}) {
+	if !l.enabled(LevelDebug) {
+		return
+	}
 	_, file, line, _ := runtime.Caller(1)
 	logWithCallerInfo(file, line, "DEBUG", message, l.logger, args...)
 }
@@ -102,34 +152,3 @@ func (l *MyLogger) Debug(message string, args ...interface{}) {
 func getFormattedTime() string {
 	return time.Now().Format("2006-01-02 15:04:05")
 }
-
-// checkAndRotateLogFile проверяет размер файла лога и при необходимости выполняет его ротацию.
-func (l *MyLogger) checkAndRotateLogFile() {
-	for {
-		time.Sleep(time.Minute) // Пауза для проверки раз в минуту (настраивайте по желанию)
-		l.fileSizeMutex.Lock()
-		stat, err := l.logFile.Stat()
-		if err != nil {
-			l.logger.Println("Ошибка при получении информации о файле логов:", err)
-			l.fileSizeMutex.Unlock()
-			time.Sleep(time.Minute) // Делаем паузу если ошибка
-			continue
-		}
-
-		if stat.Size() > maxLogFileSize {
-			_ = l.logFile.Close()
-			err = os.Rename("app.log", "app.old.log")
-			if err != nil {
-				l.logger.Println("Ошибка при переименовании файла логов:", err)
-			}
-			newFile, err := os.OpenFile("app.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-			if err != nil {
-				l.logger.Println("Ошибка при открытии нового файла журнала:", err)
-			} else {
-				l.logFile = newFile
-				l.logger.SetOutput(l.logFile)
-			}
-		}
-		l.fileSizeMutex.Unlock()
-	}
-}
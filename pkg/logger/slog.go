@@ -0,0 +1,230 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Форматы записей журнала
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// LevelFatal Уровень slog для Fatal, записывается всегда
+const LevelFatal = slog.LevelError + 4
+
+// SlogLogger Журнал на основе log/slog с записями в формате JSON или текста ключ=значение.
+// Реализует LoggersInterface, поэтому подходит для всех существующих вызовов.
+//
+// Сообщение с аргументами форматируется как в fmt.Sprintf, если содержит %,
+// иначе аргументы записываются как атрибуты ключ-значение: l.Info("запрос выполнен", "id", id).
+type SlogLogger struct {
+	handler slog.Handler
+	level   *slog.LevelVar
+	exit    func(code int)
+}
+
+// NewSlog Создаёт журнал, по умолчанию текстовый формат в stdout с уровнем debug и местом вызова
+func NewSlog(opts ...SlogOption) (*SlogLogger, error) {
+	o := &slogOptions{format: FormatText, level: "debug", source: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	level := new(slog.LevelVar)
+	l := &SlogLogger{level: level, exit: os.Exit}
+	if err := l.SetLevel(o.level); err != nil {
+		return nil, err
+	}
+
+	var w io.Writer = os.Stdout
+	switch len(o.outputs) {
+	case 0:
+	case 1:
+		w = o.outputs[0]
+	default:
+		w = io.MultiWriter(o.outputs...)
+	}
+
+	hopts := &slog.HandlerOptions{
+		AddSource:   o.source,
+		Level:       level,
+		ReplaceAttr: replaceAttr,
+	}
+	switch o.format {
+	case FormatJSON:
+		l.handler = slog.NewJSONHandler(w, hopts)
+	case FormatText:
+		l.handler = slog.NewTextHandler(w, hopts)
+	default:
+		return nil, fmt.Errorf("неизвестный формат журнала %q, допустимые значения: json, text", o.format)
+	}
+
+	return l, nil
+}
+
+// Discard Возвращает журнал, который отбрасывает все записи, например для тестов
+func Discard() *SlogLogger {
+	l, _ := NewSlog(Output(io.Discard))
+	return l
+}
+
+// SetLevel Устанавливает уровень журнала, может вызываться во время работы
+func (l *SlogLogger) SetLevel(name string) error {
+	level, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	l.level.Set(slogLevels[level])
+	return nil
+}
+
+// With Возвращает журнал, добавляющий атрибуты ключ-значение ко всем записям
+func (l *SlogLogger) With(args ...interface{}) LoggersInterface {
+	if len(args) == 0 {
+		return l
+	}
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(args...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return &SlogLogger{handler: l.handler.WithAttrs(attrs), level: l.level, exit: l.exit}
+}
+
+// Slog Возвращает *slog.Logger с тем же обработчиком для библиотек, принимающих slog
+func (l *SlogLogger) Slog() *slog.Logger {
+	return slog.New(l.handler)
+}
+
+func (l *SlogLogger) Error(message string, err error) {
+	if err != nil {
+		l.log(slog.LevelError, message, []interface{}{"error", err.Error()})
+		return
+	}
+	l.log(slog.LevelError, message, nil)
+}
+
+func (l *SlogLogger) Info(message string, args ...interface{}) {
+	l.log(slog.LevelInfo, message, args)
+}
+
+func (l *SlogLogger) Warn(message string, args ...interface{}) {
+	l.log(slog.LevelWarn, message, args)
+}
+
+// Fatal Записывает сообщение независимо от уровня и завершает приложение с кодом 1
+func (l *SlogLogger) Fatal(message string, err error) {
+	if err != nil {
+		l.log(LevelFatal, message, []interface{}{"error", err.Error()})
+	} else {
+		l.log(LevelFatal, message, nil)
+	}
+	l.exit(1)
+}
+
+func (l *SlogLogger) Debug(message string, args ...interface{}) {
+	l.log(slog.LevelDebug, message, args)
+}
+
+// log Записывает сообщение с местом вызова метода журнала
+func (l *SlogLogger) log(level slog.Level, message string, args []interface{}) {
+	ctx := context.Background()
+	if !l.handler.Enabled(ctx, level) {
+		return
+	}
+
+	// Пропускаются runtime.Callers, log и метод журнала
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+
+	if len(args) > 0 && strings.Contains(message, "%") {
+		message, args = fmt.Sprintf(message, args...), nil
+	}
+	r := slog.NewRecord(time.Now(), level, message, pcs[0])
+	r.Add(args...)
+	_ = l.handler.Handle(ctx, r)
+}
+
+// slogLevels Соответствие уровней журнала уровням slog
+var slogLevels = map[int32]slog.Level{
+	LevelDebug: slog.LevelDebug,
+	LevelInfo:  slog.LevelInfo,
+	LevelWarn:  slog.LevelWarn,
+	LevelError: slog.LevelError,
+}
+
+// replaceAttr Называет уровень Fatal и сокращает путь к файлу места вызова
+func replaceAttr(_ []string, a slog.Attr) slog.Attr {
+	switch a.Key {
+	case slog.LevelKey:
+		if level, ok := a.Value.Any().(slog.Level); ok && level >= LevelFatal {
+			return slog.String(slog.LevelKey, "FATAL")
+		}
+	case slog.SourceKey:
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			return slog.String(slog.SourceKey, fmt.Sprintf("%s:%d", shortPath(src.File), src.Line))
+		}
+	}
+	return a
+}
+
+// shortPath Оставляет каталог пакета и имя файла: controller/hgandlers.go
+func shortPath(path string) string {
+	i := strings.LastIndexByte(path, '/')
+	if i < 0 {
+		return path
+	}
+	if j := strings.LastIndexByte(path[:i], '/'); j >= 0 {
+		return path[j+1:]
+	}
+	return path
+}
+
+// slogOptions Параметры NewSlog
+type slogOptions struct {
+	format  string
+	level   string
+	source  bool
+	outputs []io.Writer
+}
+
+// SlogOption -.
+type SlogOption func(*slogOptions)
+
+// Format Формат записей: json или text
+func Format(format string) SlogOption {
+	return func(o *slogOptions) {
+		o.format = format
+	}
+}
+
+// Level Начальный уровень журнала: debug, info, warn или error
+func Level(level string) SlogOption {
+	return func(o *slogOptions) {
+		o.level = level
+	}
+}
+
+// Source Добавлять к записям файл и строку вызова
+func Source(enabled bool) SlogOption {
+	return func(o *slogOptions) {
+		o.source = enabled
+	}
+}
+
+// Output Добавляет получателя записей, без получателей записи пишутся в stdout
+func Output(w io.Writer) SlogOption {
+	return func(o *slogOptions) {
+		o.outputs = append(o.outputs, w)
+	}
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestSlog(t *testing.T, level string) (*SlogLogger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	l, err := NewSlog(Format(FormatJSON), Level(level), Output(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, &buf
+}
+
+func decodeRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("запись %q не в формате JSON: %v", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestSlogLogger(t *testing.T) {
+	l, buf := newTestSlog(t, "info")
+
+	l.Debug("не записывается")
+	l.Info("Выгружено объявлений: %d", 5)
+	l.With("request_id", "abc").Warn("медленный запрос", "duration_ms", 1200)
+	l.Error("Ошибка при поиске", errors.New("нет соединения"))
+
+	records := decodeRecords(t, buf)
+	if len(records) != 3 {
+		t.Fatalf("записей %d, ожидалось 3: %s", len(records), buf)
+	}
+	if records[0]["msg"] != "Выгружено объявлений: 5" || records[0]["level"] != "INFO" {
+		t.Errorf("форматированное сообщение: %v", records[0])
+	}
+	if records[1]["request_id"] != "abc" || records[1]["duration_ms"] != float64(1200) {
+		t.Errorf("атрибуты: %v", records[1])
+	}
+	if records[2]["error"] != "нет соединения" {
+		t.Errorf("ошибка: %v", records[2])
+	}
+	if src, _ := records[0]["source"].(string); !strings.HasPrefix(src, "logger/slog_test.go:") {
+		t.Errorf("место вызова %q, ожидался файл теста", src)
+	}
+}
+
+func TestSlogLogger_SetLevelAndFatal(t *testing.T) {
+	l, buf := newTestSlog(t, "error")
+	code := 0
+	l.exit = func(c int) { code = c }
+
+	l.Warn("не записывается")
+	if err := l.SetLevel("warn"); err != nil {
+		t.Fatal(err)
+	}
+	l.Warn("записывается")
+	l.Fatal("остановка", nil)
+
+	records := decodeRecords(t, buf)
+	if len(records) != 2 || records[1]["level"] != "FATAL" || code != 1 {
+		t.Errorf("записи %v, код выхода %d", records, code)
+	}
+}
+
+func TestWith_Fallback(t *testing.T) {
+	l := &captureLogger{}
+	With(With(l, "a", 1), "b", "x").Info("сообщение")
+	if l.last != "a=1 b=x сообщение" {
+		t.Errorf("сообщение %q", l.last)
+	}
+}
+
+type captureLogger struct{ last string }
+
+func (c *captureLogger) Error(message string, _ error)          { c.last = message }
+func (c *captureLogger) Info(message string, _ ...interface{})  { c.last = message }
+func (c *captureLogger) Warn(message string, _ ...interface{})  { c.last = message }
+func (c *captureLogger) Fatal(message string, _ error)          { c.last = message }
+func (c *captureLogger) Debug(message string, _ ...interface{}) { c.last = message }
+
+func TestDiscard(t *testing.T) {
+	l := Discard()
+	// Запись на любом уровне не должна ничего выводить и не должна завершать процесс
+	l.Debug("отладка")
+	l.Error("ошибка", nil)
+	if l.Slog() == nil {
+		t.Error("Discard вернул журнал без обработчика")
+	}
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldLogger Журнал с атрибутами ключ-значение
+type FieldLogger interface {
+	LoggersInterface
+	With(args ...interface{}) LoggersInterface
+}
+
+// With Возвращает журнал, добавляющий атрибуты ключ-значение к записям.
+// Журналы без поддержки атрибутов получают их в начале сообщения: key=value.
+func With(l LoggersInterface, args ...interface{}) LoggersInterface {
+	if len(args) == 0 {
+		return l
+	}
+	if fl, ok := l.(FieldLogger); ok {
+		return fl.With(args...)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, "%v=%v ", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&b, "%v ", args[i])
+		}
+	}
+	return &prefixLogger{l: l, prefix: b.String()}
+}
+
+// prefixLogger Добавляет атрибуты в начало сообщения
+type prefixLogger struct {
+	l      LoggersInterface
+	prefix string
+}
+
+func (p *prefixLogger) With(args ...interface{}) LoggersInterface {
+	next := With(p.l, args...).(*prefixLogger)
+	next.prefix = p.prefix + next.prefix
+	return next
+}
+
+func (p *prefixLogger) Error(message string, err error) {
+	p.l.Error(p.prefix+message, err)
+}
+
+func (p *prefixLogger) Info(message string, args ...interface{}) {
+	p.l.Info(p.prefix+message, args...)
+}
+
+func (p *prefixLogger) Warn(message string, args ...interface{}) {
+	p.l.Warn(p.prefix+message, args...)
+}
+
+func (p *prefixLogger) Fatal(message string, err error) {
+	p.l.Fatal(p.prefix+message, err)
+}
+
+func (p *prefixLogger) Debug(message string, args ...interface{}) {
+	p.l.Debug(p.prefix+message, args...)
+}
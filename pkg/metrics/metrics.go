@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+	"net/http"
+	"strconv"
+	"time"
+	"zatrasz75/Ads_service/pkg/httpx"
+)
+
+const _defaultNamespace = "ads"
+
+// Metrics Метрики сервиса в формате Prometheus: HTTP запросы, операции хранилища, пул соединений MongoDB
+// и метрики среды выполнения Go. Методы nil *Metrics ничего не делают, поэтому метрики можно не подключать.
+type Metrics struct {
+	namespace string
+	registry  *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+	httpPanics   *prometheus.CounterVec
+
+	repoDuration *prometheus.HistogramVec
+	repoErrors   *prometheus.CounterVec
+
+	poolOpen     prometheus.Gauge
+	poolInUse    prometheus.Gauge
+	poolFailures *prometheus.CounterVec
+}
+
+// New Создаёт метрики в собственном реестре
+func New(opts ...Option) *Metrics {
+	m := &Metrics{
+		namespace: _defaultNamespace,
+		registry:  prometheus.NewRegistry(),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace, Subsystem: "http", Name: "requests_total",
+		Help: "Количество HTTP запросов по маршруту, методу и коду ответа.",
+	}, []string{"method", "route", "code"})
+	m.httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace, Subsystem: "http", Name: "request_duration_seconds",
+		Help:    "Длительность обработки HTTP запросов.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+	m.httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: m.namespace, Subsystem: "http", Name: "requests_in_flight",
+		Help: "Количество HTTP запросов, обрабатываемых в данный момент.",
+	})
+	m.httpPanics = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace, Subsystem: "http", Name: "panics_total",
+		Help: "Количество паник в обработчиках HTTP запросов по маршруту.",
+	}, []string{"method", "route"})
+
+	m.repoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace, Subsystem: "repository", Name: "operation_duration_seconds",
+		Help:    "Длительность операций хранилища по методу.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+	m.repoErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace, Subsystem: "repository", Name: "operation_errors_total",
+		Help: "Количество ошибок операций хранилища по методу.",
+	}, []string{"method"})
+
+	m.poolOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: m.namespace, Subsystem: "mongo_pool", Name: "open_connections",
+		Help: "Открытые соединения в пуле MongoDB.",
+	})
+	m.poolInUse = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: m.namespace, Subsystem: "mongo_pool", Name: "in_use_connections",
+		Help: "Соединения пула MongoDB, занятые операциями.",
+	})
+	m.poolFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace, Subsystem: "mongo_pool", Name: "checkout_failures_total",
+		Help: "Количество неудачных попыток получить соединение из пула MongoDB по причине.",
+	}, []string{"reason"})
+
+	m.registry.MustRegister(
+		m.httpRequests, m.httpDuration, m.httpInFlight, m.httpPanics,
+		m.repoDuration, m.repoErrors,
+		m.poolOpen, m.poolInUse, m.poolFailures,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Registry Возвращает реестр для регистрации дополнительных метрик
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler Возвращает обработчик, отдающий метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.InstrumentMetricHandler(m.registry, promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// Middleware Учитывает HTTP запросы по шаблону маршрута gorilla/mux, а не по пути,
+// чтобы идентификаторы в пути не создавали отдельные ряды метрик
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.httpInFlight.Inc()
+		defer m.httpInFlight.Dec()
+
+		start := time.Now()
+		rec := httpx.NewRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		labels := prometheus.Labels{"method": r.Method, "route": httpx.RouteTemplate(r), "code": strconv.Itoa(rec.Status)}
+		m.httpRequests.With(labels).Inc()
+		m.httpDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObservePanic Учитывает панику в обработчике запроса r
+func (m *Metrics) ObservePanic(r *http.Request) {
+	if m == nil {
+		return
+	}
+	m.httpPanics.WithLabelValues(r.Method, httpx.RouteTemplate(r)).Inc()
+}
+
+// ObserveOperation Учитывает длительность и ошибку операции хранилища
+func (m *Metrics) ObserveOperation(method string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.repoDuration.WithLabelValues(method).Observe(duration.Seconds())
+	if err != nil {
+		m.repoErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// PoolMonitor Возвращает обработчик событий пула соединений MongoDB для mongo.PoolMonitor
+func (m *Metrics) PoolMonitor() *event.PoolMonitor {
+	if m == nil {
+		return nil
+	}
+	return &event.PoolMonitor{
+		Event: func(ev *event.PoolEvent) {
+			switch ev.Type {
+			case event.ConnectionCreated:
+				m.poolOpen.Inc()
+			case event.ConnectionClosed:
+				m.poolOpen.Dec()
+			case event.GetSucceeded:
+				m.poolInUse.Inc()
+			case event.ConnectionReturned:
+				m.poolInUse.Dec()
+			case event.GetFailed:
+				m.poolFailures.WithLabelValues(ev.Reason).Inc()
+			}
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_Middleware(t *testing.T) {
+	m := New()
+	r := mux.NewRouter()
+	r.Use(m.Middleware)
+	r.HandleFunc("/admin/import/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for _, id := range []string{"1", "2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin/import/"+id, nil))
+	}
+
+	got := testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodGet, "/admin/import/{id}", "404"))
+	if got != 2 {
+		t.Errorf("запросов по шаблону маршрута %v, ожидалось 2", got)
+	}
+	if got := testutil.ToFloat64(m.httpInFlight); got != 0 {
+		t.Errorf("запросов в обработке %v, ожидалось 0", got)
+	}
+}
+
+func TestMetrics_ObserveOperation(t *testing.T) {
+	m := New()
+	m.ObserveOperation("AddPost", time.Millisecond, nil)
+	m.ObserveOperation("AddPost", time.Millisecond, errors.New("ошибка"))
+
+	if got := testutil.ToFloat64(m.repoErrors.WithLabelValues("AddPost")); got != 1 {
+		t.Errorf("ошибок %v, ожидалась 1", got)
+	}
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, name := range []string{"ads_repository_operation_duration_seconds_count{method=\"AddPost\"} 2", "go_goroutines"} {
+		if !strings.Contains(rr.Body.String(), name) {
+			t.Errorf("в ответе нет %s", name)
+		}
+	}
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+	m.ObserveOperation("AddPost", time.Millisecond, nil)
+	if m.PoolMonitor() != nil {
+		t.Error("ожидался nil PoolMonitor")
+	}
+	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	if m.Middleware(h) == nil {
+		t.Error("ожидался исходный обработчик")
+	}
+}
@@ -0,0 +1,11 @@
+package metrics
+
+// Option -.
+type Option func(*Metrics)
+
+// Namespace Префикс имён метрик
+func Namespace(namespace string) Option {
+	return func(m *Metrics) {
+		m.namespace = namespace
+	}
+}
@@ -2,58 +2,199 @@ package mongo
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"math/rand"
+	"strconv"
 	"time"
 	"zatrasz75/Ads_service/pkg/logger"
 )
 
+const (
+	_defaultConnAttempts = 5
+	_defaultConnTimeout  = time.Second
+	_defaultConnDeadline = 30 * time.Second
+	// _maxRetryDelay Предельная пауза между попытками подключения
+	_maxRetryDelay = 30 * time.Second
+)
+
 // Mongo Хранилище данных.
 type Mongo struct {
 	connAttempts   int
 	connTimeout    time.Duration
+	connDeadline   time.Duration
 	dbName         string
 	collectionName string
 
+	maxPoolSize            uint64
+	minPoolSize            uint64
+	maxConnIdleTime        time.Duration
+	serverSelectionTimeout time.Duration
+	socketTimeout          time.Duration
+	readPreference         string
+	writeConcern           string
+	tlsConfig              *tls.Config
+	poolMonitor            *event.PoolMonitor
+
 	M *mongo.Client
 }
 
 // New Конструктор, принимает строку подключения к БД.
+// Подключение повторяется с экспоненциально растущей паузой, пока не исчерпаны попытки
+// или не истекло общее время на подключение.
 func New(constr string, l logger.LoggersInterface, opts ...Option) (*Mongo, error) {
-	m := &Mongo{}
+	m := &Mongo{
+		connAttempts: _defaultConnAttempts,
+		connTimeout:  _defaultConnTimeout,
+		connDeadline: _defaultConnDeadline,
+	}
 
 	// Пользовательские параметры
 	for _, opt := range opts {
 		opt(m)
 	}
 
-	mongoOpts := options.Client().ApplyURI(constr)
+	mongoOpts, err := m.clientOptions(constr)
+	if err != nil {
+		return nil, err
+	}
+
+	// mongo.Connect не устанавливает соединение, поэтому доступность проверяется через Ping
+	client, err := mongo.Connect(context.Background(), mongoOpts)
+	if err != nil {
+		return nil, err
+	}
 
-	var client *mongo.Client
-	var err error
+	ctx, cancel := context.WithTimeout(context.Background(), m.connDeadline)
+	defer cancel()
 
-	for m.connAttempts > 0 {
-		client, err = mongo.Connect(context.Background(), mongoOpts)
+	delay := m.connTimeout
+	for attempt := 1; ; attempt++ {
+		err = client.Ping(ctx, readpref.Primary())
 		if err == nil {
-			// Проверяем, что подключение действительно было установлено
-			err = client.Ping(context.Background(), nil)
-			if err == nil {
-				// Подключение успешно, выходим из цикла
-				break
-			}
+			break
+		}
+		if attempt >= m.connAttempts {
+			break
 		}
-		l.Info("MongoDB пытается подключиться, попыток осталось: %d", m.connAttempts)
-
-		time.Sleep(m.connTimeout)
 
-		m.connAttempts--
+		wait := jitter(delay)
+		l.Info("MongoDB пытается подключиться, попыток осталось: %d, следующая через %s", m.connAttempts-attempt, wait.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+		if ctx.Err() != nil {
+			err = fmt.Errorf("истекло время подключения %s: %w", m.connDeadline, err)
+			break
+		}
+		delay = min(delay*2, _maxRetryDelay)
 	}
 	if err != nil {
+		_ = client.Disconnect(context.Background())
 		return nil, err
 	}
 
-	s := Mongo{
-		M: client,
+	m.M = client
+	return m, nil
+}
+
+// Database Возвращает базу данных из параметров подключения
+func (m *Mongo) Database() *mongo.Database {
+	return m.M.Database(m.dbName)
+}
+
+// Collection Возвращает коллекцию базы данных, пустое имя означает коллекцию из параметров подключения
+func (m *Mongo) Collection(name string) *mongo.Collection {
+	if name == "" {
+		name = m.collectionName
+	}
+	return m.Database().Collection(name)
+}
+
+// Close Закрывает соединения с базой данных, ожидая завершения операций не дольше, чем позволяет ctx
+func (m *Mongo) Close(ctx context.Context) error {
+	if m.M == nil {
+		return nil
+	}
+	return m.M.Disconnect(ctx)
+}
+
+// Ping Проверяет доступность базы данных
+func (m *Mongo) Ping(ctx context.Context) error {
+	return m.M.Ping(ctx, nil)
+}
+
+// clientOptions Собирает параметры клиента драйвера
+func (m *Mongo) clientOptions(constr string) (*options.ClientOptions, error) {
+	opts := options.Client().ApplyURI(constr)
+
+	if m.maxPoolSize > 0 {
+		opts.SetMaxPoolSize(m.maxPoolSize)
+	}
+	if m.minPoolSize > 0 {
+		opts.SetMinPoolSize(m.minPoolSize)
+	}
+	if m.maxConnIdleTime > 0 {
+		opts.SetMaxConnIdleTime(m.maxConnIdleTime)
+	}
+	if m.serverSelectionTimeout > 0 {
+		opts.SetServerSelectionTimeout(m.serverSelectionTimeout)
+	}
+	if m.socketTimeout > 0 {
+		opts.SetSocketTimeout(m.socketTimeout)
+	}
+	if m.readPreference != "" {
+		mode, err := readpref.ModeFromString(m.readPreference)
+		if err != nil {
+			return nil, err
+		}
+		rp, err := readpref.New(mode)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetReadPreference(rp)
+	}
+	if m.writeConcern != "" {
+		wc, err := parseWriteConcern(m.writeConcern)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetWriteConcern(wc)
+	}
+	if m.tlsConfig != nil {
+		opts.SetTLSConfig(m.tlsConfig)
+	}
+	if m.poolMonitor != nil {
+		opts.SetPoolMonitor(m.poolMonitor)
+	}
+
+	return opts, opts.Validate()
+}
+
+// parseWriteConcern Разбирает уровень подтверждения записи: majority или количество узлов
+func parseWriteConcern(w string) (*writeconcern.WriteConcern, error) {
+	if w == "majority" {
+		return writeconcern.Majority(), nil
+	}
+	n, err := strconv.Atoi(w)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("неизвестный уровень подтверждения записи %q, ожидается majority или число", w)
+	}
+	return &writeconcern.WriteConcern{W: n}, nil
+}
+
+// jitter Возвращает случайную паузу от половины до полной длительности, чтобы экземпляры сервиса
+// не переподключались одновременно
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
 	}
-	return &s, nil
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
 }
@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseWriteConcern(t *testing.T) {
+	for _, w := range []string{"majority", "0", "1", "3"} {
+		if _, err := parseWriteConcern(w); err != nil {
+			t.Errorf("parseWriteConcern(%q): %v", w, err)
+		}
+	}
+	for _, w := range []string{"all", "-1"} {
+		if _, err := parseWriteConcern(w); err == nil {
+			t.Errorf("parseWriteConcern(%q): ожидалась ошибка", w)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	d := time.Second
+	for i := 0; i < 100; i++ {
+		got := jitter(d)
+		if got < d/2 || got > d {
+			t.Fatalf("jitter(%s) = %s, ожидалось от %s до %s", d, got, d/2, d)
+		}
+	}
+	if got := jitter(0); got != 0 {
+		t.Errorf("jitter(0) = %s", got)
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	m := &Mongo{readPreference: "secondaryPreferred", writeConcern: "majority", maxPoolSize: 10, minPoolSize: 2}
+	opts, err := m.clientOptions("mongodb://localhost:27017")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *opts.MaxPoolSize != 10 || *opts.MinPoolSize != 2 {
+		t.Errorf("размер пула %d/%d, ожидалось 10/2", *opts.MaxPoolSize, *opts.MinPoolSize)
+	}
+	if opts.ReadPreference.Mode().String() != "secondaryPreferred" {
+		t.Errorf("узлы чтения %s", opts.ReadPreference.Mode())
+	}
+
+	m = &Mongo{readPreference: "anywhere"}
+	if _, err = m.clientOptions("mongodb://localhost:27017"); err == nil {
+		t.Error("ожидалась ошибка для неизвестного режима чтения")
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	cfg, err := TLSConfig("", "", "", false)
+	if err != nil || cfg.RootCAs != nil || len(cfg.Certificates) != 0 {
+		t.Errorf("без файлов: %v, %+v", err, cfg)
+	}
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	if err = os.WriteFile(ca, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = TLSConfig(ca, "", "", false); err == nil {
+		t.Error("ожидалась ошибка для файла CA без сертификатов")
+	}
+	if _, err = TLSConfig("", ca, "", false); err == nil {
+		t.Error("ожидалась ошибка для сертификата без ключа")
+	}
+}
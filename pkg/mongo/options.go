@@ -1,6 +1,10 @@
 package mongo
 
-import "time"
+import (
+	"crypto/tls"
+	"go.mongodb.org/mongo-driver/event"
+	"time"
+)
 
 // Option -.
 type Option func(mongo *Mongo)
@@ -41,3 +45,55 @@ func collectionNameOption(collectionName string) Option {
 		m.collectionName = collectionName
 	}
 }
+
+// ConnDeadline Общее время на подключение со всеми попытками
+func ConnDeadline(deadline time.Duration) Option {
+	return func(m *Mongo) {
+		m.connDeadline = deadline
+	}
+}
+
+// Pool Размер пула соединений и время жизни простаивающего соединения, нулевые значения не меняют параметры драйвера
+func Pool(maxSize, minSize uint64, maxIdle time.Duration) Option {
+	return func(m *Mongo) {
+		m.maxPoolSize = maxSize
+		m.minPoolSize = minSize
+		m.maxConnIdleTime = maxIdle
+	}
+}
+
+// Timeouts Время выбора сервера и ожидания ответа на сокете, нулевые значения не меняют параметры драйвера
+func Timeouts(serverSelection, socket time.Duration) Option {
+	return func(m *Mongo) {
+		m.serverSelectionTimeout = serverSelection
+		m.socketTimeout = socket
+	}
+}
+
+// ReadPreference Узлы для чтения: primary, primaryPreferred, secondary, secondaryPreferred или nearest
+func ReadPreference(mode string) Option {
+	return func(m *Mongo) {
+		m.readPreference = mode
+	}
+}
+
+// WriteConcern Уровень подтверждения записи: majority или количество узлов
+func WriteConcern(w string) Option {
+	return func(m *Mongo) {
+		m.writeConcern = w
+	}
+}
+
+// TLS Параметры TLS соединения
+func TLS(cfg *tls.Config) Option {
+	return func(m *Mongo) {
+		m.tlsConfig = cfg
+	}
+}
+
+// PoolMonitor Обработчик событий пула соединений, например для метрик
+func PoolMonitor(monitor *event.PoolMonitor) Option {
+	return func(m *Mongo) {
+		m.poolMonitor = monitor
+	}
+}
@@ -0,0 +1,13 @@
+package recovery
+
+import "net/http"
+
+// Option -.
+type Option func(*Recovery)
+
+// OnPanic Функция, вызываемая после перехвата паники, например для учёта в метриках
+func OnPanic(fn func(r *http.Request)) Option {
+	return func(rc *Recovery) {
+		rc.onPanic = fn
+	}
+}
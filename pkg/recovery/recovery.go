@@ -0,0 +1,96 @@
+package recovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"runtime/debug"
+	"zatrasz75/Ads_service/pkg/httpx"
+	"zatrasz75/Ads_service/pkg/logger"
+	"zatrasz75/Ads_service/pkg/requestid"
+	"zatrasz75/Ads_service/pkg/tracing"
+)
+
+// Problem Ответ об ошибке в формате RFC 9457 (application/problem+json)
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Recovery Перехватывает панику в обработчиках запросов
+type Recovery struct {
+	l       logger.LoggersInterface
+	onPanic func(r *http.Request)
+}
+
+// New Создаёт перехватчик паник, стек вызовов записывается в журнал l
+func New(l logger.LoggersInterface, opts ...Option) *Recovery {
+	rc := &Recovery{l: l}
+	for _, opt := range opts {
+		opt(rc)
+	}
+	return rc
+}
+
+// Middleware Превращает панику в обработчике в ответ 500 с идентификатором запроса.
+// Значение паники и стек вызовов записываются только в журнал, клиент их не получает.
+// Если ответ уже начат, соединение закрывается через http.ErrAbortHandler.
+func (rc *Recovery) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httpx.NewRecorder(w)
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			// Обработчик сам прервал ответ, это не ошибка сервиса
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+
+			err := fmt.Errorf("паника: %v", v)
+			trace.SpanFromContext(r.Context()).RecordError(err)
+			l := tracing.Logger(r.Context(), logger.FromContext(r.Context(), rc.l))
+			logger.With(l, "stack", string(debug.Stack())).Error("Паника при обработке запроса", err)
+			if rc.onPanic != nil {
+				rc.onPanic(r)
+			}
+
+			if rec.WroteHeader() {
+				panic(http.ErrAbortHandler)
+			}
+			writeProblem(w, r)
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// writeProblem Отвечает 500 без подробностей об ошибке
+func writeProblem(w http.ResponseWriter, r *http.Request) {
+	p := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(http.StatusInternalServerError),
+		Status:    http.StatusInternalServerError,
+		Detail:    "Внутренняя ошибка сервера, сообщите идентификатор запроса в поддержку",
+		Instance:  r.URL.Path,
+		RequestID: requestid.FromContext(r.Context()),
+	}
+
+	h := w.Header()
+	// Заголовки содержимого, выставленные обработчиком до паники, к ответу об ошибке не относятся.
+	// Заголовки промежуточных обработчиков, например X-Request-ID, сохраняются.
+	for _, k := range []string{"Content-Length", "Content-Disposition", "ETag", "Last-Modified"} {
+		h.Del(k)
+	}
+	h.Set("Cache-Control", "no-store")
+	h.Set("Content-Type", "application/problem+json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusInternalServerError)
+	_ = json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,71 @@
+package recovery
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"zatrasz75/Ads_service/pkg/requestid"
+)
+
+type captureLogger struct {
+	message string
+	err     error
+}
+
+func (c *captureLogger) Error(message string, err error) { c.message, c.err = message, err }
+func (c *captureLogger) Info(string, ...interface{})     {}
+func (c *captureLogger) Warn(string, ...interface{})     {}
+func (c *captureLogger) Fatal(string, error)             {}
+func (c *captureLogger) Debug(string, ...interface{})    {}
+
+func TestMiddleware(t *testing.T) {
+	l := &captureLogger{}
+	panics := 0
+
+	r := mux.NewRouter()
+	r.Use(requestid.Middleware(l), New(l, OnPanic(func(*http.Request) { panics++ })).Middleware)
+	r.HandleFunc("/posts", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		panic(errors.New("пароль от базы: secret"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.Header.Set(requestid.Header, "req-1")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError || rr.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("код %d, Content-Type %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if strings.Contains(rr.Body.String(), "secret") {
+		t.Errorf("ответ содержит значение паники: %s", rr.Body)
+	}
+	var p Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil || p.RequestID != "req-1" || p.Status != 500 {
+		t.Errorf("ответ %s, ошибка %v", rr.Body, err)
+	}
+	if panics != 1 || l.err == nil || !strings.Contains(l.err.Error(), "secret") {
+		t.Errorf("паник учтено %d, в журнале %v", panics, l.err)
+	}
+	if !strings.Contains(l.message, "stack=") {
+		t.Errorf("в журнале нет стека вызовов: %q", l.message)
+	}
+}
+
+func TestMiddleware_ResponseStarted(t *testing.T) {
+	h := New(&captureLogger{}).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("начало"))
+		panic("сбой")
+	}))
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("ожидалась http.ErrAbortHandler, получено %v", v)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
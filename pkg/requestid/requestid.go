@@ -0,0 +1,71 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"zatrasz75/Ads_service/pkg/httpx"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// Header Заголовок с идентификатором запроса
+const Header = "X-Request-ID"
+
+// maxLength Предельная длина входящего идентификатора, более длинный заменяется новым
+const maxLength = 128
+
+// ctxKey Ключ идентификатора запроса в контексте
+type ctxKey struct{}
+
+// Middleware Принимает идентификатор запроса из заголовка X-Request-ID или создаёт новый,
+// возвращает его в ответе и кладёт в контекст журнал запроса с атрибутами request_id, method, route и remote.
+// Шаблон маршрута известен только после сопоставления, поэтому подключается через Router.Use.
+func Middleware(l logger.LoggersInterface) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(Header)
+			if !valid(id) {
+				id = New()
+			}
+			w.Header().Set(Header, id)
+
+			rl := logger.With(l,
+				"request_id", id,
+				"method", r.Method,
+				"route", httpx.RouteTemplate(r),
+				"remote", r.RemoteAddr,
+			)
+			ctx := context.WithValue(r.Context(), ctxKey{}, id)
+			ctx = logger.NewContext(ctx, rl)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// FromContext Возвращает идентификатор запроса из ctx или пустую строку
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// New Создаёт случайный идентификатор из 32 шестнадцатеричных символов
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// valid Проверяет входящий идентификатор: непустой, не длиннее maxLength, только видимые символы ASCII,
+// чтобы значение из заголовка нельзя было использовать для подделки строк журнала
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
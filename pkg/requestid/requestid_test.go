@@ -0,0 +1,66 @@
+package requestid
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+type captureLogger struct{ last string }
+
+func (c *captureLogger) Error(message string, _ error)          { c.last = message }
+func (c *captureLogger) Info(message string, _ ...interface{})  { c.last = message }
+func (c *captureLogger) Warn(message string, _ ...interface{})  { c.last = message }
+func (c *captureLogger) Fatal(message string, _ error)          { c.last = message }
+func (c *captureLogger) Debug(message string, _ ...interface{}) { c.last = message }
+
+func TestMiddleware(t *testing.T) {
+	base := &captureLogger{}
+	var gotID string
+
+	r := mux.NewRouter()
+	r.Use(Middleware(base))
+	r.HandleFunc("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		gotID = FromContext(r.Context())
+		logger.FromContext(r.Context(), nil).Info("обработан")
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "входящий", incoming: "abc-123", keep: true},
+		{name: "без заголовка"},
+		{name: "с переводом строки", incoming: "abc\nrequest_id=fake"},
+		{name: "слишком длинный", incoming: strings.Repeat("a", maxLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/posts/42", nil)
+			if tt.incoming != "" {
+				req.Header.Set(Header, tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			id := rr.Header().Get(Header)
+			if tt.keep && id != tt.incoming {
+				t.Errorf("идентификатор %q, ожидался входящий %q", id, tt.incoming)
+			}
+			if !tt.keep && len(id) != 32 {
+				t.Errorf("идентификатор %q, ожидался новый", id)
+			}
+			if gotID != id {
+				t.Errorf("в контексте %q, в ответе %q", gotID, id)
+			}
+			want := "request_id=" + id + " method=GET route=/posts/{id} "
+			if !strings.HasPrefix(base.last, want) {
+				t.Errorf("запись журнала %q, ожидалось начало %q", base.last, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Теги, которыми выделяются найденные слова
+const (
+	HighlightOpen  = "<em>"
+	HighlightClose = "</em>"
+)
+
+type span struct {
+	start, end int // позиции в рунах
+	match      bool
+}
+
+// Highlight Возвращает фрагмент текста длиной до maxLen символов вокруг первого совпадения со словами запроса.
+// Найденные слова обрамляются тегами <em></em>, остальной текст экранируется для HTML.
+// Если совпадений нет, возвращается пустая строка.
+func Highlight(text, query string, maxLen int) string {
+	stems := make(map[string]struct{})
+	for _, token := range Tokenize(query) {
+		stems[Stem(token)] = struct{}{}
+	}
+	if len(stems) == 0 {
+		return ""
+	}
+
+	runes := []rune(text)
+	words := wordSpans(runes)
+	first := -1
+	for i := range words {
+		if _, ok := stems[Stem(string(runes[words[i].start:words[i].end]))]; ok {
+			words[i].match = true
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	// Окно начинается немного раньше первого совпадения, по границе слова
+	start, end := 0, len(runes)
+	if maxLen > 0 && len(runes) > maxLen {
+		start = words[first].start - maxLen/4
+		for _, w := range words {
+			if w.start >= start {
+				start = w.start
+				break
+			}
+		}
+		if start < 0 {
+			start = 0
+		}
+		end = start + maxLen
+		if end > len(runes) {
+			end = len(runes)
+			start = end - maxLen
+		}
+		// Конец окна не должен разрезать слово
+		for _, w := range words {
+			if w.start < end && w.end > end {
+				end = w.start
+				break
+			}
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, w := range words {
+		if !w.match || w.start < start || w.end > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:w.start])))
+		b.WriteString(HighlightOpen)
+		b.WriteString(html.EscapeString(string(runes[w.start:w.end])))
+		b.WriteString(HighlightClose)
+		pos = w.end
+	}
+	b.WriteString(html.EscapeString(strings.TrimRightFunc(string(runes[pos:end]), unicode.IsSpace)))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// wordSpans Возвращает позиции слов в тексте
+func wordSpans(runes []rune) []span {
+	var spans []span
+	start := -1
+	for i, r := range runes {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, span{start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, span{start: start, end: len(runes)})
+	}
+	return spans
+}
@@ -0,0 +1,124 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Field Индексируемое поле документа и его вес при ранжировании
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// Result Найденный документ и его релевантность
+type Result struct {
+	ID    string
+	Score float64
+}
+
+// Index Инвертированный индекс в памяти процесса.
+// Используется как замена текстовому индексу MongoDB для хранилищ без полнотекстового поиска.
+type Index struct {
+	mu       sync.RWMutex
+	postings map[string]map[string]float64 // основа слова -> документ -> взвешенная частота
+	docs     map[string][]string           // документ -> основы слов, для удаления
+}
+
+// NewIndex Создаёт пустой индекс
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[string]float64),
+		docs:     make(map[string][]string),
+	}
+}
+
+// Add Добавляет или заменяет документ в индексе
+func (idx *Index) Add(id string, fields ...Field) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+
+	weights := make(map[string]float64)
+	for _, f := range fields {
+		for _, token := range Tokenize(f.Text) {
+			weights[Stem(token)] += f.Weight
+		}
+	}
+
+	stems := make([]string, 0, len(weights))
+	for stem, w := range weights {
+		if idx.postings[stem] == nil {
+			idx.postings[stem] = make(map[string]float64)
+		}
+		idx.postings[stem][id] = w
+		stems = append(stems, stem)
+	}
+	idx.docs[id] = stems
+}
+
+// Remove Удаляет документ из индекса
+func (idx *Index) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+func (idx *Index) remove(id string) {
+	for _, stem := range idx.docs[id] {
+		delete(idx.postings[stem], id)
+		if len(idx.postings[stem]) == 0 {
+			delete(idx.postings, stem)
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// Len Количество документов в индексе
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Search Ищет документы, содержащие хотя бы одно слово запроса, и упорядочивает их по TF-IDF
+func (idx *Index) Search(query string) []Result {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	total := float64(len(idx.docs))
+	scores := make(map[string]float64)
+	seen := make(map[string]struct{})
+
+	for _, token := range Tokenize(query) {
+		stem := Stem(token)
+		if _, ok := seen[stem]; ok {
+			continue
+		}
+		seen[stem] = struct{}{}
+
+		postings := idx.postings[stem]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := math.Log(1 + total/float64(len(postings)))
+		for id, tf := range postings {
+			scores[id] += math.Log1p(tf) * idf
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, Result{ID: id, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	return results
+}
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	groups := [][]string{
+		{"велосипед", "велосипеды", "велосипедов", "велосипедами"},
+		{"красная", "красный", "красные"},
+		{"продаю", "продаётся", "продается"},
+		{"bicycle", "bicycles"},
+		{"running", "run", "runs"},
+	}
+	for _, group := range groups {
+		want := Stem(group[0])
+		for _, word := range group[1:] {
+			if got := Stem(word); got != want {
+				t.Errorf("Stem(%q) = %q, ожидали %q (как у %q)", word, got, want, group[0])
+			}
+		}
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	if got := DetectLanguage("Продаю велосипед Stels"); got != Russian {
+		t.Errorf("Получили %s, ожидали %s", got, Russian)
+	}
+	if got := DetectLanguage("Selling a red bicycle"); got != English {
+		t.Errorf("Получили %s, ожидали %s", got, English)
+	}
+}
+
+func TestIndex_Search(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("1", Field{Text: "Велосипед горный", Weight: 3}, Field{Text: "Продаю горный велосипед, почти новый", Weight: 1})
+	idx.Add("2", Field{Text: "Самокат", Weight: 3}, Field{Text: "Подойдёт вместо велосипеда", Weight: 1})
+	idx.Add("3", Field{Text: "Red bicycle", Weight: 3}, Field{Text: "Selling bicycles", Weight: 1})
+
+	results := idx.Search("велосипеды")
+	if len(results) != 2 || results[0].ID != "1" || results[1].ID != "2" {
+		t.Fatalf("Ожидали документы 1 и 2 по убыванию релевантности, получили %v", results)
+	}
+
+	if results = idx.Search("bicycle"); len(results) != 1 || results[0].ID != "3" {
+		t.Errorf("Ожидали документ 3, получили %v", results)
+	}
+
+	idx.Remove("1")
+	if results = idx.Search("велосипед"); len(results) != 1 || results[0].ID != "2" {
+		t.Errorf("После удаления ожидали только документ 2, получили %v", results)
+	}
+	if idx.Len() != 2 {
+		t.Errorf("Ожидали 2 документа в индексе, получили %d", idx.Len())
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got := Highlight("Продаю горный велосипед <б/у>", "велосипеды", 0)
+	want := "Продаю горный <em>велосипед</em> &lt;б/у&gt;"
+	if got != want {
+		t.Errorf("Получили %q, ожидали %q", got, want)
+	}
+
+	long := strings.Repeat("слово ", 50) + "велосипед " + strings.Repeat("слово ", 50)
+	got = Highlight(long, "велосипед", 60)
+	if !strings.Contains(got, "<em>велосипед</em>") || !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("Фрагмент не содержит совпадения или многоточий: %q", got)
+	}
+
+	if got = Highlight("Самокат", "велосипед", 0); got != "" {
+		t.Errorf("Без совпадений ожидали пустую строку, получили %q", got)
+	}
+}
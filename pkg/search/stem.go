@@ -0,0 +1,217 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Поддерживаемые языки, названия совпадают с языками текстовых индексов MongoDB
+const (
+	Russian = "russian"
+	English = "english"
+)
+
+// DetectLanguage Определяет язык текста по преобладающему алфавиту.
+// Если букв нет, возвращается русский язык.
+func DetectLanguage(text string) string {
+	var cyrillic, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	if latin > cyrillic {
+		return English
+	}
+	return Russian
+}
+
+// Tokenize Разбивает текст на слова в нижнем регистре
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Stem Возвращает основу слова. Язык выбирается по алфавиту самого слова,
+// поэтому смешанный текст обрабатывается корректно.
+func Stem(word string) string {
+	word = strings.ToLower(word)
+	if DetectLanguage(word) == English {
+		return stemEnglish(word)
+	}
+	return stemRussian(strings.ReplaceAll(word, "ё", "е"))
+}
+
+// Окончания для стеммера русского языка (упрощённый алгоритм Snowball)
+var (
+	ruPerfectiveGerund = [][]string{
+		{"вшись", "вши", "в"},                            // после а/я
+		{"ывшись", "ившись", "ывши", "ивши", "ыв", "ив"}, // без условия
+	}
+	ruReflexive  = []string{"ся", "сь"}
+	ruAdjective  = []string{"ими", "ыми", "его", "ого", "ему", "ому", "ее", "ие", "ые", "ое", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею"}
+	ruParticiple = [][]string{
+		{"ем", "нн", "вш", "ющ", "щ"}, // после а/я
+		{"ивш", "ывш", "ующ"},         // без условия
+	}
+	ruVerb = [][]string{
+		{"ете", "йте", "ешь", "нно", "ла", "на", "ли", "ем", "ло", "но", "ет", "ют", "ны", "ть", "й", "л", "н"},
+		{"уйте", "ейте", "ила", "ыла", "ена", "ите", "или", "ыли", "ило", "ыло", "ено", "ует", "уют", "ены", "ить", "ыть", "ишь", "ей", "уй", "ил", "ыл", "им", "ым", "ен", "ят", "ит", "ыт", "ую", "ю"},
+	}
+	ruNoun         = []string{"иями", "ями", "ами", "ией", "иям", "ием", "иях", "ев", "ов", "ие", "ье", "еи", "ии", "ей", "ой", "ий", "ям", "ем", "ам", "ом", "ах", "ях", "ию", "ью", "ия", "ья", "а", "е", "и", "й", "о", "у", "ы", "ь", "ю", "я"}
+	ruSuperlative  = []string{"ейше", "ейш"}
+	ruDerivational = []string{"ость", "ост"}
+	ruVowels       = "аеиоуыэюя"
+)
+
+// stemRussian Упрощённая реализация стеммера Snowball для русского языка
+func stemRussian(word string) string {
+	runes := []rune(word)
+	rv := ruRegion(runes)
+	if rv >= len(runes) {
+		return word
+	}
+	prefix, region := string(runes[:rv]), string(runes[rv:])
+
+	// Шаг 1: деепричастия, иначе возвратные окончания и прилагательные/глаголы/существительные
+	if s, ok := trimConditional(region, ruPerfectiveGerund); ok {
+		region = s
+	} else {
+		region = trimAny(region, ruReflexive)
+		if s, ok := trimSuffix(region, ruAdjective); ok {
+			region = s
+			if s, ok := trimConditional(region, ruParticiple); ok {
+				region = s
+			}
+		} else if s, ok := trimConditional(region, ruVerb); ok {
+			region = s
+		} else {
+			region = trimAny(region, ruNoun)
+		}
+	}
+
+	// Шаг 2: окончание "и"
+	region = strings.TrimSuffix(region, "и")
+
+	// Шаг 3: словообразовательные суффиксы в области R2
+	if s, ok := trimSuffix(region, ruDerivational); ok && ruR2(prefix, region, s) {
+		region = s
+	}
+
+	// Шаг 4: превосходная степень, двойное "н" и мягкий знак
+	region = trimAny(region, ruSuperlative)
+	if strings.HasSuffix(region, "нн") {
+		region = strings.TrimSuffix(region, "н")
+	} else {
+		region = strings.TrimSuffix(region, "ь")
+	}
+
+	return prefix + region
+}
+
+// ruRegion Возвращает начало области RV: позицию после первой гласной
+func ruRegion(runes []rune) int {
+	for i, r := range runes {
+		if strings.ContainsRune(ruVowels, r) {
+			return i + 1
+		}
+	}
+	return len(runes)
+}
+
+// ruR2 Проверяет, что отсекаемый суффикс лежит в области R2
+func ruR2(prefix, region, trimmed string) bool {
+	runes := []rune(prefix + region)
+	r1 := regionAfterVC(runes, 0)
+	r2 := regionAfterVC(runes, r1)
+	return len([]rune(prefix+trimmed)) >= r2
+}
+
+// regionAfterVC Возвращает позицию после первой пары "гласная-согласная", начиная с from
+func regionAfterVC(runes []rune, from int) int {
+	for i := from + 1; i < len(runes); i++ {
+		if !strings.ContainsRune(ruVowels, runes[i]) && strings.ContainsRune(ruVowels, runes[i-1]) {
+			return i + 1
+		}
+	}
+	return len(runes)
+}
+
+// trimConditional Отсекает окончание из первой группы, только если перед ним "а" или "я",
+// либо окончание из второй группы без условия
+func trimConditional(word string, groups [][]string) (string, bool) {
+	best, bestLen := "", 0
+	for _, suffix := range groups[1] {
+		if strings.HasSuffix(word, suffix) && len(suffix) > bestLen {
+			best, bestLen = strings.TrimSuffix(word, suffix), len(suffix)
+		}
+	}
+	for _, suffix := range groups[0] {
+		if len(suffix) <= bestLen || !strings.HasSuffix(word, suffix) {
+			continue
+		}
+		rest := strings.TrimSuffix(word, suffix)
+		if strings.HasSuffix(rest, "а") || strings.HasSuffix(rest, "я") {
+			best, bestLen = rest, len(suffix)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// trimSuffix Отсекает самое длинное подходящее окончание
+func trimSuffix(word string, suffixes []string) (string, bool) {
+	best, bestLen := word, 0
+	for _, suffix := range suffixes {
+		if len(suffix) > bestLen && strings.HasSuffix(word, suffix) {
+			best, bestLen = strings.TrimSuffix(word, suffix), len(suffix)
+		}
+	}
+	return best, bestLen > 0
+}
+
+func trimAny(word string, suffixes []string) string {
+	s, _ := trimSuffix(word, suffixes)
+	return s
+}
+
+// Окончания для упрощённого стеммера английского языка, от длинных к коротким
+var enSuffixes = []struct {
+	suffix, replace string
+}{
+	{"ational", "ate"}, {"ization", "ize"}, {"fulness", "ful"}, {"ousness", "ous"}, {"iveness", "ive"},
+	{"tional", "tion"}, {"biliti", "ble"}, {"ements", ""}, {"ations", "ate"}, {"ement", ""}, {"ation", "ate"},
+	{"alism", "al"}, {"aliti", "al"}, {"ness", ""}, {"ment", ""}, {"ings", ""}, {"sses", "ss"}, {"ies", "y"},
+	{"ing", ""}, {"ers", ""}, {"est", ""}, {"ful", ""}, {"ly", ""}, {"ed", ""}, {"er", ""}, {"es", ""}, {"s", ""},
+}
+
+// stemEnglish Упрощённый стеммер английского языка: отсекает распространённые суффиксы
+// и конечное "e", оставляя основу не короче трёх букв
+func stemEnglish(word string) string {
+	if len(word) <= 3 || strings.HasSuffix(word, "ss") {
+		return word
+	}
+	stem := word
+	for _, s := range enSuffixes {
+		if !strings.HasSuffix(word, s.suffix) {
+			continue
+		}
+		trimmed := strings.TrimSuffix(word, s.suffix) + s.replace
+		if len(trimmed) < 3 {
+			break
+		}
+		stem = trimmed
+		// Удвоенная согласная после отсечения -ing/-ed: running -> run
+		if (s.suffix == "ing" || s.suffix == "ed") && len(stem) > 3 && stem[len(stem)-1] == stem[len(stem)-2] && !strings.ContainsRune("aeioulsz", rune(stem[len(stem)-1])) {
+			stem = stem[:len(stem)-1]
+		}
+		break
+	}
+	if len(stem) > 3 && strings.HasSuffix(stem, "e") {
+		stem = strings.TrimSuffix(stem, "e")
+	}
+	return stem
+}
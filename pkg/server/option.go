@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"net"
 	"time"
 )
@@ -46,3 +47,75 @@ func ShutdownTimeout(sTimeout time.Duration) Option {
 		s.shutdownTimeout = sTimeout
 	}
 }
+
+// OnShutdown Функция, вызываемая в начале Shutdown, например чтобы проверка готовности перестала проходить
+func OnShutdown(fn func()) Option {
+	return func(s *Server) {
+		s.onShutdown = append(s.onShutdown, fn)
+	}
+}
+
+// DrainDelay Пауза после OnShutdown перед остановкой сервера: за это время балансировщик перестаёт направлять запросы
+func DrainDelay(delay time.Duration) Option {
+	return func(s *Server) {
+		s.drainDelay = delay
+	}
+}
+
+// OnStop Функция, вызываемая после остановки сервера со своим сроком StopTimeout,
+// например закрытие соединений с базой данных, которыми пользовались обработчики
+func OnStop(fn func(ctx context.Context) error) Option {
+	return func(s *Server) {
+		s.onStop = append(s.onStop, fn)
+	}
+}
+
+// StopTimeout Время на выполнение функций OnStop, отсчитывается после остановки сервера.
+// По умолчанию равно ShutdownTimeout.
+func StopTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.stopTimeout = timeout
+	}
+}
+
+// ReadHeaderTimeout Время на чтение заголовков запроса, защищает от медленных клиентов
+func ReadHeaderTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.server.ReadHeaderTimeout = timeout
+	}
+}
+
+// MaxHeaderBytes Предельный размер заголовков запроса в байтах
+func MaxHeaderBytes(n int) Option {
+	return func(s *Server) {
+		s.server.MaxHeaderBytes = n
+	}
+}
+
+// TLS Включает HTTPS с сертификатом и ключом из файлов. Сертификат перечитывается при изменении файлов.
+func TLS(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.certFile, s.keyFile = certFile, keyFile
+	}
+}
+
+// HTTP2 Разрешает HTTP/2 для соединений TLS, по умолчанию разрешён
+func HTTP2(enabled bool) Option {
+	return func(s *Server) {
+		s.http2 = enabled
+	}
+}
+
+// Unix Слушать сокет Unix вместо TCP адреса, например за nginx на том же узле
+func Unix(path string) Option {
+	return func(s *Server) {
+		s.socketPath = path
+	}
+}
+
+// Listener Обслуживать заранее открытый слушатель, например переданный systemd или созданный в тестах
+func Listener(ln net.Listener) Option {
+	return func(s *Server) {
+		s.listener = ln
+	}
+}
@@ -2,7 +2,12 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"os"
 	"time"
 )
 
@@ -10,6 +15,15 @@ type Server struct {
 	server          *http.Server
 	notify          chan error
 	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+	stopTimeout     time.Duration
+	onShutdown      []func()
+	onStop          []func(ctx context.Context) error
+
+	listener          net.Listener
+	socketPath        string
+	certFile, keyFile string
+	http2             bool
 }
 
 func New(router http.Handler, opts ...Option) *Server {
@@ -20,6 +34,7 @@ func New(router http.Handler, opts ...Option) *Server {
 	s := &Server{
 		server: httpserver,
 		notify: make(chan error, 1),
+		http2:  true,
 	}
 
 	for _, opt := range opts {
@@ -29,22 +44,103 @@ func New(router http.Handler, opts ...Option) *Server {
 	return s
 }
 
-func (s *Server) Start() error {
-	err := s.server.ListenAndServe()
+// Start Запускает сервер в отдельной горутине. Ошибки открытия адреса и работы сервера передаются в Notify,
+// остановка через Shutdown ошибкой не считается.
+func (s *Server) Start() {
+	go func() {
+		defer close(s.notify)
+		if err := s.serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.notify <- err
+		}
+	}()
+}
+
+// serve Открывает адрес и обслуживает запросы до остановки сервера
+func (s *Server) serve() error {
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	if !s.http2 {
+		// Непустая карта отключает автоматическое согласование HTTP/2
+		s.server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+
+	if s.certFile == "" {
+		return s.server.Serve(ln)
+	}
+	cert, err := loadCertificate(s.certFile, s.keyFile)
 	if err != nil {
+		_ = ln.Close()
 		return err
 	}
+	if s.server.TLSConfig == nil {
+		s.server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	s.server.TLSConfig.GetCertificate = cert.get
+	return s.server.ServeTLS(ln, "", "")
+}
 
-	return nil
+// listen Возвращает заранее открытый слушатель, сокет Unix или TCP адрес сервера
+func (s *Server) listen() (net.Listener, error) {
+	switch {
+	case s.listener != nil:
+		return s.listener, nil
+	case s.socketPath != "":
+		// Сокет, оставшийся после аварийного завершения, мешает открыть адрес
+		if err := os.Remove(s.socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("не удалось удалить старый сокет %s: %w", s.socketPath, err)
+		}
+		return net.Listen("unix", s.socketPath)
+	default:
+		return net.Listen("tcp", s.server.Addr)
+	}
 }
 
+// Notify Возвращает канал с ошибкой работы сервера. Канал закрывается после остановки сервера.
 func (s *Server) Notify() <-chan error {
 	return s.notify
 }
 
+// Shutdown Вызывает функции OnShutdown, выжидает drainDelay и останавливает сервер,
+// дожидаясь завершения текущих запросов не дольше shutdownTimeout. Запросы, не успевшие завершиться,
+// прерываются закрытием соединений. После остановки сервера вызываются функции OnStop
+// с отдельным сроком stopTimeout, например для закрытия соединений с базой данных:
+// даже если запросы не успели завершиться, у OnStop остаётся время на работу.
 func (s *Server) Shutdown() error {
+	for _, fn := range s.onShutdown {
+		fn()
+	}
+	if s.drainDelay > 0 {
+		time.Sleep(s.drainDelay)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
 
-	return s.server.Shutdown(ctx)
+	err := s.server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = errors.Join(fmt.Errorf("запросы не завершились за %s: %w", s.shutdownTimeout, err), s.server.Close())
+	}
+
+	return errors.Join(err, s.stop())
+}
+
+// stop Вызывает функции OnStop с контекстом, ограниченным stopTimeout, а если он не задан, shutdownTimeout
+func (s *Server) stop() error {
+	timeout := s.stopTimeout
+	if timeout <= 0 {
+		timeout = s.shutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var err error
+	for _, fn := range s.onStop {
+		if stopErr := fn(ctx); stopErr != nil {
+			err = errors.Join(err, stopErr)
+		}
+	}
+	return err
 }
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServer_NotifyListenError(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	host, port, _ := net.SplitHostPort(busy.Addr().String())
+	s := New(http.NotFoundHandler(), Addr(host, port))
+	s.Start()
+
+	select {
+	case err = <-s.Notify():
+		if err == nil {
+			t.Error("ожидалась ошибка занятого адреса")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ошибка запуска не передана в Notify")
+	}
+}
+
+func TestServer_ShutdownDrains(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	var stopped bool
+	s := New(handler, Listener(ln), ShutdownTimeout(5*time.Second), OnStop(func(context.Context) error {
+		stopped = true
+		return nil
+	}))
+	s.Start()
+
+	// Запрос, начатый до остановки, должен получить ответ
+	resp := make(chan error, 1)
+	go func() {
+		r, err := http.Get("http://" + ln.Addr().String())
+		if err == nil {
+			r.Body.Close()
+		}
+		resp <- err
+	}()
+	<-started
+
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown() }()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if err = <-resp; err != nil {
+		t.Errorf("запрос прерван: %v", err)
+	}
+	if err = <-done; err != nil || !stopped {
+		t.Errorf("Shutdown: %v, OnStop вызван: %v", err, stopped)
+	}
+	if err, ok := <-s.Notify(); ok {
+		t.Errorf("остановка передана как ошибка: %v", err)
+	}
+}
+
+func TestServer_ShutdownTimeoutStop(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	// Запрос не успевает завершиться, но OnStop получает живой контекст со своим сроком
+	var stopErr error
+	s := New(handler, Listener(ln), ShutdownTimeout(50*time.Millisecond), StopTimeout(time.Second),
+		OnStop(func(ctx context.Context) error {
+			stopErr = ctx.Err()
+			if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) < 500*time.Millisecond {
+				stopErr = errors.New("срок OnStop не отделён от срока остановки сервера")
+			}
+			return nil
+		}))
+	s.Start()
+
+	go func() {
+		if r, err := http.Get("http://" + ln.Addr().String()); err == nil {
+			r.Body.Close()
+		}
+	}()
+	<-started
+
+	if err = s.Shutdown(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() = %v, ожидалось превышение срока", err)
+	}
+	if stopErr != nil {
+		t.Errorf("OnStop: %v", stopErr)
+	}
+}
+
+func TestServer_TLSOverUnixSocket(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, "first")
+	socket := filepath.Join(dir, "ads.sock")
+
+	s := New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}), Unix(socket), TLS(certFile, keyFile), ShutdownTimeout(time.Second))
+	s.Start()
+	defer s.Shutdown()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+
+	var resp *http.Response
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if resp, err = client.Get("https://localhost/"); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 || resp.TLS == nil || resp.TLS.PeerCertificates[0].Subject.CommonName != "first" {
+		t.Errorf("протокол %s, TLS %v", resp.Proto, resp.TLS != nil)
+	}
+}
+
+func TestCertificate_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, "first")
+
+	c, err := loadCertificate(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.interval = 0
+
+	// Новый сертификат с более поздним временем изменения файлов
+	writeCert(t, certFile, keyFile, "second")
+	later := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, later, later)
+
+	cert, _ := c.get(nil)
+	leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+	if leaf.Subject.CommonName != "second" {
+		t.Errorf("сертификат %q, ожидался перечитанный", leaf.Subject.CommonName)
+	}
+
+	// Испорченный файл не заменяет действующий сертификат
+	_ = os.WriteFile(keyFile, []byte("broken"), 0o600)
+	_ = os.Chtimes(keyFile, later.Add(time.Minute), later.Add(time.Minute))
+	if cert, _ = c.get(nil); cert == nil {
+		t.Error("после ошибки чтения сертификат потерян")
+	}
+}
+
+// writeCert Записывает самоподписанный сертификат с именем cn
+func writeCert(t *testing.T, certFile, keyFile, cn string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// _certCheckInterval Как часто проверяется изменение файлов сертификата
+const _certCheckInterval = 10 * time.Second
+
+// certificate Сертификат сервера из файлов, перечитывается при их изменении без перезапуска,
+// например после продления сертификата cert-manager или certbot
+type certificate struct {
+	certFile, keyFile string
+	interval          time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// loadCertificate Загружает сертификат и ключ, ошибка в файлах обнаруживается при запуске
+func loadCertificate(certFile, keyFile string) (*certificate, error) {
+	c := &certificate{certFile: certFile, keyFile: keyFile, interval: _certCheckInterval}
+	if err := c.reload(time.Now()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// get Возвращает действующий сертификат для tls.Config.GetCertificate.
+// Если новые файлы не удалось прочитать, используется прежний сертификат.
+func (c *certificate) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.checkedAt) >= c.interval {
+		c.checkedAt = now
+		if c.changed() {
+			_ = c.reload(now)
+		}
+	}
+	return c.cert, nil
+}
+
+// changed Сообщает, изменился ли какой-либо из файлов после последней загрузки
+func (c *certificate) changed() bool {
+	for _, f := range []string{c.certFile, c.keyFile} {
+		if st, err := os.Stat(f); err == nil && st.ModTime().After(c.modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *certificate) reload(now time.Time) error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("не удалось загрузить сертификат сервера: %w", err)
+	}
+	var modTime time.Time
+	for _, f := range []string{c.certFile, c.keyFile} {
+		if st, err := os.Stat(f); err == nil && st.ModTime().After(modTime) {
+			modTime = st.ModTime()
+		}
+	}
+	c.cert, c.modTime, c.checkedAt = &cert, modTime, now
+	return nil
+}
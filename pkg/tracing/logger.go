@@ -0,0 +1,17 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/trace"
+	"zatrasz75/Ads_service/pkg/logger"
+)
+
+// Logger Возвращает журнал с атрибутами trace_id и span_id из ctx.
+// Без трассировки в ctx возвращается l.
+func Logger(ctx context.Context, l logger.LoggersInterface) logger.LoggersInterface {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return l
+	}
+	return logger.With(l, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+}
@@ -0,0 +1,39 @@
+package tracing
+
+// Option -.
+type Option func(*Provider)
+
+// Exporter Экспортёр трассировки: none, otlp, stdout или file
+func Exporter(name string) Option {
+	return func(p *Provider) {
+		p.exporter = name
+	}
+}
+
+// Endpoint Адрес приёма трассировки OTLP/HTTP, например http://localhost:4318/v1/traces
+func Endpoint(url string) Option {
+	return func(p *Provider) {
+		p.endpoint = url
+	}
+}
+
+// File Файл для экспортёра file, данные дописываются в конец в формате JSON
+func File(path string) Option {
+	return func(p *Provider) {
+		p.file = path
+	}
+}
+
+// ServiceName Имя сервиса в данных трассировки
+func ServiceName(name string) Option {
+	return func(p *Provider) {
+		p.serviceName = name
+	}
+}
+
+// SampleRatio Доля записываемых трассировок от 0 до 1, решение вызывающего сервиса из traceparent соблюдается
+func SampleRatio(ratio float64) Option {
+	return func(p *Provider) {
+		p.sampleRatio = ratio
+	}
+}
@@ -0,0 +1,139 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"os"
+)
+
+// Экспортёры трассировки
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// instrumentationName Имя библиотеки инструментирования в данных трассировки
+const instrumentationName = "zatrasz75/Ads_service"
+
+const (
+	_defaultServiceName = "ads-service"
+	_defaultSampleRatio = 1.0
+)
+
+// Provider Источник трассировки сервиса. Устанавливается глобально,
+// поэтому Start и Middleware используют его без явной передачи.
+type Provider struct {
+	exporter    string
+	endpoint    string
+	file        string
+	serviceName string
+	sampleRatio float64
+
+	tp     *sdktrace.TracerProvider
+	closer io.Closer
+}
+
+// New Настраивает глобальный источник трассировки и распространение заголовков W3C traceparent и baggage.
+// С экспортёром none заголовки распространяются, но данные трассировки никуда не отправляются.
+func New(opts ...Option) (*Provider, error) {
+	p := &Provider{
+		exporter:    ExporterNone,
+		serviceName: _defaultServiceName,
+		sampleRatio: _defaultSampleRatio,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := p.newExporter()
+	if err != nil || exporter == nil {
+		return p, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", p.serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	p.tp = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(p.sampleRatio))),
+	)
+	otel.SetTracerProvider(p.tp)
+
+	return p, nil
+}
+
+// newExporter Создаёт экспортёр по названию, для none возвращает nil
+func (p *Provider) newExporter() (sdktrace.SpanExporter, error) {
+	switch p.exporter {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterOTLP:
+		return otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(p.endpoint))
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		f, err := os.OpenFile(p.file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось открыть файл трассировки: %w", err)
+		}
+		p.closer = f
+		return stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, fmt.Errorf("неизвестный экспортёр трассировки %q, допустимые значения: none, otlp, stdout, file", p.exporter)
+	}
+}
+
+// Shutdown Отправляет накопленные данные трассировки и останавливает экспортёр
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p == nil || p.tp == nil {
+		return nil
+	}
+	err := p.tp.Shutdown(ctx)
+	if p.closer != nil {
+		if cerr := p.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Start Начинает операцию трассировки name как дочернюю для операции из ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End Завершает операцию, отмечая её ошибкой, если err не nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID Возвращает идентификатор трассировки из ctx или пустую строку
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordSpans Устанавливает глобальный источник трассировки, запоминающий завершённые операции
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	rec := recordSpans(t)
+
+	r := mux.NewRouter()
+	r.Use(Middleware)
+	r.HandleFunc("/admin/import/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "handler.getImportJob")
+		End(span, errors.New("не найдено"))
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/admin/import/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("операций %d, ожидалось 2", len(spans))
+	}
+	handler, server := spans[0], spans[1]
+	if server.Name() != "GET /admin/import/{id}" {
+		t.Errorf("имя операции %q", server.Name())
+	}
+	if server.SpanContext().TraceID().String() != traceID {
+		t.Errorf("трассировка %s, ожидалась %s из traceparent", server.SpanContext().TraceID(), traceID)
+	}
+	if server.Status().Code != codes.Error {
+		t.Errorf("статус операции %v, ожидалась ошибка для 500", server.Status())
+	}
+	if handler.Parent().SpanID() != server.SpanContext().SpanID() || handler.Status().Code != codes.Error {
+		t.Errorf("операция обработчика не вложена в операцию запроса или не отмечена ошибкой")
+	}
+}
+
+func TestLogger(t *testing.T) {
+	recordSpans(t)
+	l := &captureLogger{}
+
+	if got := Logger(context.Background(), l); got != l {
+		t.Error("без трассировки ожидался исходный журнал")
+	}
+
+	ctx, span := Start(context.Background(), "op")
+	defer span.End()
+	Logger(ctx, l).Info("сообщение %d", 1)
+	if !strings.HasPrefix(l.last, "trace_id="+TraceID(ctx)+" span_id=") {
+		t.Errorf("сообщение %q без идентификатора трассировки", l.last)
+	}
+}
+
+type captureLogger struct{ last string }
+
+func (c *captureLogger) Error(message string, _ error)          { c.last = message }
+func (c *captureLogger) Info(message string, _ ...interface{})  { c.last = message }
+func (c *captureLogger) Warn(message string, _ ...interface{})  { c.last = message }
+func (c *captureLogger) Fatal(message string, _ error)          { c.last = message }
+func (c *captureLogger) Debug(message string, _ ...interface{}) { c.last = message }